@@ -2,7 +2,9 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"log"
+	"strings"
 	"test/models"
 	"time"
 
@@ -11,6 +13,8 @@ import (
 
 type Store struct {
 	Db *sql.DB
+	// secretKey mengenkripsi secret credential (nil jika SecretKeyEnv tidak diset)
+	secretKey []byte
 }
 
 func NewStore(dbPath string) *Store {
@@ -48,6 +52,133 @@ func NewStore(dbPath string) *Store {
 		log.Printf("Could not add 'thread_count' column, it might already exist: %v", err)
 	}
 
+	// Add ping_count column (jumlah echo/dial per run untuk mode icmp/tcp)
+	_, err = db.Exec("ALTER TABLE urls ADD COLUMN ping_count INTEGER NOT NULL DEFAULT 1")
+	if err != nil {
+		log.Printf("Could not add 'ping_count' column, it might already exist: %v", err)
+	}
+
+	// Add probe_options column (JSON berisi pengaturan khusus per mode)
+	_, err = db.Exec("ALTER TABLE urls ADD COLUMN probe_options TEXT NOT NULL DEFAULT '{}'")
+	if err != nil {
+		log.Printf("Could not add 'probe_options' column, it might already exist: %v", err)
+	}
+
+	// Add kolom request HTTP per target (method, header, body)
+	_, err = db.Exec("ALTER TABLE urls ADD COLUMN http_method TEXT NOT NULL DEFAULT 'GET'")
+	if err != nil {
+		log.Printf("Could not add 'http_method' column, it might already exist: %v", err)
+	}
+	_, err = db.Exec("ALTER TABLE urls ADD COLUMN http_headers TEXT NOT NULL DEFAULT ''")
+	if err != nil {
+		log.Printf("Could not add 'http_headers' column, it might already exist: %v", err)
+	}
+	_, err = db.Exec("ALTER TABLE urls ADD COLUMN http_body TEXT NOT NULL DEFAULT ''")
+	if err != nil {
+		log.Printf("Could not add 'http_body' column, it might already exist: %v", err)
+	}
+	_, err = db.Exec("ALTER TABLE urls ADD COLUMN http_body_type TEXT NOT NULL DEFAULT ''")
+	if err != nil {
+		log.Printf("Could not add 'http_body_type' column, it might already exist: %v", err)
+	}
+
+	// Add http_assertions column (JSON daftar assertion body response)
+	_, err = db.Exec("ALTER TABLE urls ADD COLUMN http_assertions TEXT NOT NULL DEFAULT '[]'")
+	if err != nil {
+		log.Printf("Could not add 'http_assertions' column, it might already exist: %v", err)
+	}
+
+	// Add kolom timeout, redirect policy dan status code yang dianggap Up
+	_, err = db.Exec("ALTER TABLE urls ADD COLUMN timeout_ms INTEGER NOT NULL DEFAULT 5000")
+	if err != nil {
+		log.Printf("Could not add 'timeout_ms' column, it might already exist: %v", err)
+	}
+	_, err = db.Exec("ALTER TABLE urls ADD COLUMN max_redirects INTEGER NOT NULL DEFAULT 10")
+	if err != nil {
+		log.Printf("Could not add 'max_redirects' column, it might already exist: %v", err)
+	}
+	_, err = db.Exec("ALTER TABLE urls ADD COLUMN accepted_status TEXT NOT NULL DEFAULT '200'")
+	if err != nil {
+		log.Printf("Could not add 'accepted_status' column, it might already exist: %v", err)
+	}
+
+	// Add kolom keep_alive (1 = koneksi HTTP dipakai ulang, 0 = koneksi baru tiap probe)
+	_, err = db.Exec("ALTER TABLE urls ADD COLUMN keep_alive INTEGER NOT NULL DEFAULT 1")
+	if err != nil {
+		log.Printf("Could not add 'keep_alive' column, it might already exist: %v", err)
+	}
+
+	// Add kolom journey_steps (JSON langkah-langkah mode journey)
+	_, err = db.Exec("ALTER TABLE urls ADD COLUMN journey_steps TEXT NOT NULL DEFAULT '[]'")
+	if err != nil {
+		log.Printf("Could not add 'journey_steps' column, it might already exist: %v", err)
+	}
+
+	// Add kolom proxy_url (kosong = ikut proxy global, "direct" = tanpa proxy)
+	_, err = db.Exec("ALTER TABLE urls ADD COLUMN proxy_url TEXT NOT NULL DEFAULT ''")
+	if err != nil {
+		log.Printf("Could not add 'proxy_url' column, it might already exist: %v", err)
+	}
+
+	// Add kolom keluarga IP ("" = dual-stack, "4", "6") dan alamat sumber (IP atau interface)
+	_, err = db.Exec("ALTER TABLE urls ADD COLUMN ip_family TEXT NOT NULL DEFAULT ''")
+	if err != nil {
+		log.Printf("Could not add 'ip_family' column, it might already exist: %v", err)
+	}
+	_, err = db.Exec("ALTER TABLE urls ADD COLUMN source_addr TEXT NOT NULL DEFAULT ''")
+	if err != nil {
+		log.Printf("Could not add 'source_addr' column, it might already exist: %v", err)
+	}
+
+	// Add kolom resolver per target dan IP override untuk hostname target
+	_, err = db.Exec("ALTER TABLE urls ADD COLUMN dns_server TEXT NOT NULL DEFAULT ''")
+	if err != nil {
+		log.Printf("Could not add 'dns_server' column, it might already exist: %v", err)
+	}
+	_, err = db.Exec("ALTER TABLE urls ADD COLUMN resolve_ip TEXT NOT NULL DEFAULT ''")
+	if err != nil {
+		log.Printf("Could not add 'resolve_ip' column, it might already exist: %v", err)
+	}
+	// Resolver mode dns dulu disimpan sebagai opsi dns_server di probe_options;
+	// pindahkan ke kolom dns_server yang sekarang dipakai semua mode
+	_, err = db.Exec(`UPDATE urls SET
+			dns_server = json_extract(probe_options, '$.dns_server'),
+			probe_options = json_remove(probe_options, '$.dns_server')
+		WHERE json_type(probe_options, '$.dns_server') = 'text'`)
+	if err != nil {
+		log.Printf("Could not migrate 'dns_server' option: %v", err)
+	}
+
+	// Add kolom fan-out (probe setiap IP hasil resolve) dan status degraded run terakhir
+	_, err = db.Exec("ALTER TABLE urls ADD COLUMN fan_out INTEGER NOT NULL DEFAULT 0")
+	if err != nil {
+		log.Printf("Could not add 'fan_out' column, it might already exist: %v", err)
+	}
+	_, err = db.Exec("ALTER TABLE urls ADD COLUMN degraded INTEGER NOT NULL DEFAULT 0")
+	if err != nil {
+		log.Printf("Could not add 'degraded' column, it might already exist: %v", err)
+	}
+
+	// Add kolom sertifikat per target (0 = tidak dipakai) dan skip verify TLS
+	_, err = db.Exec("ALTER TABLE urls ADD COLUMN client_cert_id INTEGER NOT NULL DEFAULT 0")
+	if err != nil {
+		log.Printf("Could not add 'client_cert_id' column, it might already exist: %v", err)
+	}
+	_, err = db.Exec("ALTER TABLE urls ADD COLUMN ca_bundle_id INTEGER NOT NULL DEFAULT 0")
+	if err != nil {
+		log.Printf("Could not add 'ca_bundle_id' column, it might already exist: %v", err)
+	}
+	_, err = db.Exec("ALTER TABLE urls ADD COLUMN tls_skip_verify INTEGER NOT NULL DEFAULT 0")
+	if err != nil {
+		log.Printf("Could not add 'tls_skip_verify' column, it might already exist: %v", err)
+	}
+
+	// Add kolom credential_id (0 = tanpa autentikasi)
+	_, err = db.Exec("ALTER TABLE urls ADD COLUMN credential_id INTEGER NOT NULL DEFAULT 0")
+	if err != nil {
+		log.Printf("Could not add 'credential_id' column, it might already exist: %v", err)
+	}
+
 	// --- TABEL SETTINGS ---
 	createSettingsTableSQL := `
 	CREATE TABLE IF NOT EXISTS settings (
@@ -70,6 +201,12 @@ func NewStore(dbPath string) *Store {
 		log.Fatalf("Gagal set default scheduler thread count: %v", err)
 	}
 
+	// Proxy global untuk probe (kosong = langsung / environment)
+	_, err = db.Exec("INSERT OR IGNORE INTO settings (key, value) VALUES ('proxy_url', '')")
+	if err != nil {
+		log.Fatalf("Gagal set default proxy: %v", err)
+	}
+
 	// --- TABEL PROBE HISTORY ---
 	createHistoryTableSQL := `
 	CREATE TABLE IF NOT EXISTS probe_history (
@@ -102,6 +239,221 @@ func NewStore(dbPath string) *Store {
 		log.Printf("Could not add 'description' column, it might already exist: %v", err)
 	}
 
+	// Add kolom statistik ping (min/avg/max RTT, jitter, packet loss)
+	for _, col := range []string{"rtt_min_ms", "rtt_avg_ms", "rtt_max_ms", "jitter_ms", "packet_loss_pct"} {
+		_, err = db.Exec("ALTER TABLE probe_history ADD COLUMN " + col + " REAL DEFAULT 0")
+		if err != nil {
+			log.Printf("Could not add '%s' column, it might already exist: %v", col, err)
+		}
+	}
+
+	// Add kolom rincian fase HTTP (DNS, connect, TLS, TTFB, transfer)
+	for _, col := range []string{"dns_ms", "connect_ms", "tls_ms", "ttfb_ms", "transfer_ms"} {
+		_, err = db.Exec("ALTER TABLE probe_history ADD COLUMN " + col + " REAL DEFAULT 0")
+		if err != nil {
+			log.Printf("Could not add '%s' column, it might already exist: %v", col, err)
+		}
+	}
+
+	// Add kolom klasifikasi error dan pesan error mentah ke probe_history
+	_, err = db.Exec("ALTER TABLE probe_history ADD COLUMN error_class TEXT")
+	if err != nil {
+		log.Printf("Could not add 'error_class' column, it might already exist: %v", err)
+	}
+	_, err = db.Exec("ALTER TABLE probe_history ADD COLUMN error_message TEXT")
+	if err != nil {
+		log.Printf("Could not add 'error_message' column, it might already exist: %v", err)
+	}
+
+	// Add kolom latency handshake dan round-trip (mode ws)
+	for _, col := range []string{"ws_handshake_ms", "ws_round_trip_ms"} {
+		_, err = db.Exec("ALTER TABLE probe_history ADD COLUMN " + col + " REAL DEFAULT 0")
+		if err != nil {
+			log.Printf("Could not add '%s' column, it might already exist: %v", col, err)
+		}
+	}
+
+	// --- TABEL PROBE STEPS (hasil per langkah mode journey, per baris history) ---
+	createProbeStepsTableSQL := `
+	CREATE TABLE IF NOT EXISTS probe_steps (
+		"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		"history_id" INTEGER NOT NULL,
+		"step_index" INTEGER NOT NULL,
+		"name" TEXT,
+		"status_code" INTEGER,
+		"latency_ms" INTEGER,
+		"passed" INTEGER,
+		"error" TEXT,
+		FOREIGN KEY(history_id) REFERENCES probe_history(id) ON DELETE CASCADE
+	);`
+	_, err = db.Exec(createProbeStepsTableSQL)
+	if err != nil {
+		log.Fatalf("Gagal membuat tabel probe_steps: %v", err)
+	}
+	_, err = db.Exec("CREATE INDEX IF NOT EXISTS idx_probe_steps_history ON probe_steps(history_id)")
+	if err != nil {
+		log.Printf("Could not create probe_steps index: %v", err)
+	}
+
+	// --- TABEL PROBE ADDRESSES (hasil per IP target fan-out, per baris history) ---
+	createProbeAddressesTableSQL := `
+	CREATE TABLE IF NOT EXISTS probe_addresses (
+		"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		"history_id" INTEGER NOT NULL,
+		"ip" TEXT NOT NULL,
+		"status_code" INTEGER,
+		"latency_ms" INTEGER,
+		"passed" INTEGER,
+		"error" TEXT,
+		FOREIGN KEY(history_id) REFERENCES probe_history(id) ON DELETE CASCADE
+	);`
+	_, err = db.Exec(createProbeAddressesTableSQL)
+	if err != nil {
+		log.Fatalf("Gagal membuat tabel probe_addresses: %v", err)
+	}
+	_, err = db.Exec("CREATE INDEX IF NOT EXISTS idx_probe_addresses_history ON probe_addresses(history_id)")
+	if err != nil {
+		log.Printf("Could not create probe_addresses index: %v", err)
+	}
+
+	// --- TABEL TRACEROUTES (diambil otomatis saat target berubah Up -> Down) ---
+	createTraceroutesTableSQL := `
+	CREATE TABLE IF NOT EXISTS traceroutes (
+		"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		"url_id" INTEGER NOT NULL,
+		"history_id" INTEGER,
+		"timestamp" DATETIME,
+		"method" TEXT,
+		"destination" TEXT,
+		"reached" INTEGER,
+		"error" TEXT,
+		FOREIGN KEY(url_id) REFERENCES urls(id) ON DELETE CASCADE
+	);`
+	_, err = db.Exec(createTraceroutesTableSQL)
+	if err != nil {
+		log.Fatalf("Gagal membuat tabel traceroutes: %v", err)
+	}
+	_, err = db.Exec("CREATE INDEX IF NOT EXISTS idx_traceroutes_url ON traceroutes(url_id)")
+	if err != nil {
+		log.Printf("Could not create traceroutes index: %v", err)
+	}
+
+	createTracerouteHopsTableSQL := `
+	CREATE TABLE IF NOT EXISTS traceroute_hops (
+		"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		"traceroute_id" INTEGER NOT NULL,
+		"ttl" INTEGER NOT NULL,
+		"ip" TEXT,
+		"rtt_ms" REAL,
+		"reached" INTEGER,
+		FOREIGN KEY(traceroute_id) REFERENCES traceroutes(id) ON DELETE CASCADE
+	);`
+	_, err = db.Exec(createTracerouteHopsTableSQL)
+	if err != nil {
+		log.Fatalf("Gagal membuat tabel traceroute_hops: %v", err)
+	}
+	_, err = db.Exec("CREATE INDEX IF NOT EXISTS idx_traceroute_hops_traceroute ON traceroute_hops(traceroute_id)")
+	if err != nil {
+		log.Printf("Could not create traceroute_hops index: %v", err)
+	}
+
+	// --- TABEL CONTENT (konten body terakhir dan riwayat perubahannya, opsi content_watch) ---
+	createContentSnapshotsTableSQL := `
+	CREATE TABLE IF NOT EXISTS content_snapshots (
+		"url_id" INTEGER NOT NULL PRIMARY KEY,
+		"hash" TEXT NOT NULL,
+		"content" TEXT,
+		"truncated" INTEGER NOT NULL DEFAULT 0,
+		"updated_at" DATETIME,
+		FOREIGN KEY(url_id) REFERENCES urls(id) ON DELETE CASCADE
+	);`
+	_, err = db.Exec(createContentSnapshotsTableSQL)
+	if err != nil {
+		log.Fatalf("Gagal membuat tabel content_snapshots: %v", err)
+	}
+
+	createContentChangesTableSQL := `
+	CREATE TABLE IF NOT EXISTS content_changes (
+		"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		"url_id" INTEGER NOT NULL,
+		"history_id" INTEGER,
+		"timestamp" DATETIME,
+		"old_hash" TEXT,
+		"new_hash" TEXT,
+		"added" INTEGER,
+		"removed" INTEGER,
+		"diff" TEXT,
+		FOREIGN KEY(url_id) REFERENCES urls(id) ON DELETE CASCADE
+	);`
+	_, err = db.Exec(createContentChangesTableSQL)
+	if err != nil {
+		log.Fatalf("Gagal membuat tabel content_changes: %v", err)
+	}
+	_, err = db.Exec("CREATE INDEX IF NOT EXISTS idx_content_changes_url ON content_changes(url_id)")
+	if err != nil {
+		log.Printf("Could not create content_changes index: %v", err)
+	}
+
+	// --- TABEL TLS INFO (sertifikat terakhir per URL, untuk mode tls) ---
+	createTLSInfoTableSQL := `
+	CREATE TABLE IF NOT EXISTS tls_info (
+		"url_id" INTEGER NOT NULL PRIMARY KEY,
+		"not_after" DATETIME,
+		"issuer" TEXT,
+		"sans" TEXT,
+		"chain_valid" INTEGER,
+		"tls_version" TEXT,
+		"checked_at" DATETIME,
+		FOREIGN KEY(url_id) REFERENCES urls(id) ON DELETE CASCADE
+	);`
+	_, err = db.Exec(createTLSInfoTableSQL)
+	if err != nil {
+		log.Fatalf("Gagal membuat tabel tls_info: %v", err)
+	}
+
+	// --- TABEL CERTIFICATES (client cert/key untuk mTLS dan CA bundle) ---
+	createCertificatesTableSQL := `
+	CREATE TABLE IF NOT EXISTS certificates (
+		"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		"name" TEXT NOT NULL,
+		"kind" TEXT NOT NULL,
+		"cert_pem" TEXT NOT NULL,
+		"key_pem" TEXT NOT NULL DEFAULT '',
+		"subject" TEXT,
+		"not_after" DATETIME,
+		"created_at" DATETIME
+	);`
+	_, err = db.Exec(createCertificatesTableSQL)
+	if err != nil {
+		log.Fatalf("Gagal membuat tabel certificates: %v", err)
+	}
+
+	// --- TABEL CREDENTIALS (secret terenkripsi AES-GCM, lihat secrets.go) ---
+	createCredentialsTableSQL := `
+	CREATE TABLE IF NOT EXISTS credentials (
+		"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		"name" TEXT NOT NULL,
+		"kind" TEXT NOT NULL,
+		"username" TEXT NOT NULL DEFAULT '',
+		"header_name" TEXT NOT NULL DEFAULT '',
+		"token_url" TEXT NOT NULL DEFAULT '',
+		"scopes" TEXT NOT NULL DEFAULT '',
+		"secret" BLOB NOT NULL,
+		"created_at" DATETIME
+	);`
+	_, err = db.Exec(createCredentialsTableSQL)
+	if err != nil {
+		log.Fatalf("Gagal membuat tabel credentials: %v", err)
+	}
+
+	// Add kolom OAuth2 (token endpoint dan scope) untuk tabel credentials lama
+	for _, column := range []string{"token_url", "scopes"} {
+		_, err = db.Exec("ALTER TABLE credentials ADD COLUMN " + column + " TEXT NOT NULL DEFAULT ''")
+		if err != nil {
+			log.Printf("Could not add '%s' column, it might already exist: %v", column, err)
+		}
+	}
+
 	// Inisialisasi kolom probe_mode untuk data yang sudah ada
 	_, err = db.Exec("UPDATE urls SET probe_mode = 'http' WHERE probe_mode IS NULL")
 	if err != nil {
@@ -114,7 +466,12 @@ func NewStore(dbPath string) *Store {
 		log.Fatalf("Gagal mengupdate thread_count untuk data yang ada: %v", err)
 	}
 
-	return &Store{Db: db}
+	secretKey := secretKeyFromEnv()
+	if secretKey == nil {
+		log.Printf("%s tidak diset: credential tidak bisa dibuat atau dipakai", SecretKeyEnv)
+	}
+
+	return &Store{Db: db, secretKey: secretKey}
 }
 
 // --- FUNGSI SETTINGS ---
@@ -149,9 +506,31 @@ func (s *Store) SetSchedulerThreadCount(count int) error {
 	return err
 }
 
+// GetProxyURL mengembalikan proxy global (dipakai target yang proxy_url-nya kosong)
+func (s *Store) GetProxyURL() (string, error) {
+	var proxyURL string
+	err := s.Db.QueryRow("SELECT value FROM settings WHERE key = 'proxy_url'").Scan(&proxyURL)
+	return proxyURL, err
+}
+
+func (s *Store) SetProxyURL(proxyURL string) error {
+	_, err := s.Db.Exec("UPDATE settings SET value = ? WHERE key = 'proxy_url'", proxyURL)
+	return err
+}
+
 // --- FUNGSI URLS ---
 func (s *Store) GetAllURLs() ([]models.TargetURL, error) {
-	rows, err := s.Db.Query("SELECT id, url, probe_mode, thread_count, last_status, last_latency_ms, last_checked, first_up_time, total_probe_count, total_latency_sum FROM urls ORDER BY id DESC")
+	rows, err := s.Db.Query(`
+		SELECT u.id, u.url, u.probe_mode, u.thread_count, u.ping_count, u.probe_options,
+			u.http_method, u.http_headers, u.http_body, u.http_body_type, u.http_assertions, u.journey_steps,
+			u.timeout_ms, u.max_redirects, u.accepted_status, u.keep_alive, u.proxy_url, u.ip_family, u.source_addr,
+			u.dns_server, u.resolve_ip, u.fan_out, u.degraded,
+			u.client_cert_id, u.ca_bundle_id, u.tls_skip_verify, u.credential_id, u.last_status, u.last_latency_ms,
+			u.last_checked, u.first_up_time, u.total_probe_count, u.total_latency_sum,
+			t.not_after, t.issuer, t.sans, t.chain_valid, t.tls_version, t.checked_at
+		FROM urls u
+		LEFT JOIN tls_info t ON t.url_id = u.id
+		ORDER BY u.id DESC`)
 	if err != nil {
 		return nil, err
 	}
@@ -161,23 +540,98 @@ func (s *Store) GetAllURLs() ([]models.TargetURL, error) {
 	for rows.Next() {
 		var u models.TargetURL
 		var lastChecked sql.NullTime
-		if err := rows.Scan(&u.ID, &u.URL, &u.ProbeMode, &u.ThreadCount, &u.LastStatus, &u.LastLatencyMs, &lastChecked, &u.FirstUpTime, &u.TotalProbeCount, &u.TotalLatencySum); err != nil {
+		var options, assertions, steps string
+		var tlsNotAfter, tlsCheckedAt sql.NullTime
+		var tlsIssuer, tlsSANs, tlsVersion sql.NullString
+		var tlsChainValid sql.NullBool
+		if err := rows.Scan(&u.ID, &u.URL, &u.ProbeMode, &u.ThreadCount, &u.PingCount, &options,
+			&u.HTTPMethod, &u.HTTPHeaders, &u.HTTPBody, &u.HTTPBodyType, &assertions, &steps,
+			&u.TimeoutMs, &u.MaxRedirects, &u.AcceptedStatus, &u.KeepAlive, &u.ProxyURL, &u.IPFamily, &u.SourceAddr,
+			&u.DNSServer, &u.ResolveIP, &u.FanOut, &u.Degraded,
+			&u.ClientCertID, &u.CABundleID, &u.TLSSkipVerify, &u.CredentialID, &u.LastStatus, &u.LastLatencyMs,
+			&lastChecked, &u.FirstUpTime, &u.TotalProbeCount, &u.TotalLatencySum,
+			&tlsNotAfter, &tlsIssuer, &tlsSANs, &tlsChainValid, &tlsVersion, &tlsCheckedAt); err != nil {
 			return nil, err
 		}
+		if tlsCheckedAt.Valid {
+			u.TLS = &models.TLSInfo{
+				NotAfter:   tlsNotAfter.Time,
+				Issuer:     tlsIssuer.String,
+				ChainValid: tlsChainValid.Bool,
+				Version:    tlsVersion.String,
+				CheckedAt:  tlsCheckedAt.Time,
+			}
+			if tlsSANs.String != "" {
+				u.TLS.SANs = strings.Split(tlsSANs.String, ",")
+			}
+		}
+		if err := json.Unmarshal([]byte(options), &u.Options); err != nil {
+			log.Printf("Invalid probe_options for URL %d: %v", u.ID, err)
+		}
+		if err := json.Unmarshal([]byte(assertions), &u.Assertions); err != nil {
+			log.Printf("Invalid http_assertions for URL %d: %v", u.ID, err)
+		}
+		if err := json.Unmarshal([]byte(steps), &u.Steps); err != nil {
+			log.Printf("Invalid journey_steps for URL %d: %v", u.ID, err)
+		}
 		if lastChecked.Valid {
 			u.LastChecked = lastChecked.Time
 		}
-		u.IsUp = (u.LastStatus == 200)
+		u.IsUp = u.IsStatusAccepted(u.LastStatus)
 		urls = append(urls, u)
 	}
 	return urls, nil
 }
 
-func (s *Store) AddURLWithMode(url string, mode string, threadCount int) error {
-	if threadCount < 1 {
-		threadCount = 1
+// AddTargetURL menyimpan target baru beserta mode dan opsinya
+func (s *Store) AddTargetURL(t models.TargetURL) error {
+	if t.ThreadCount < 1 {
+		t.ThreadCount = 1
+	}
+	if t.PingCount < 1 {
+		t.PingCount = 1
+	}
+	if t.HTTPMethod == "" {
+		t.HTTPMethod = "GET"
+	}
+	if t.TimeoutMs <= 0 {
+		t.TimeoutMs = int(models.DefaultTimeout / time.Millisecond)
+	}
+	if t.MaxRedirects < 0 {
+		t.MaxRedirects = 0
+	}
+	if t.AcceptedStatus == "" {
+		t.AcceptedStatus = "200"
+	}
+	options, err := json.Marshal(t.Options)
+	if err != nil {
+		return err
+	}
+	if t.Options == nil {
+		options = []byte("{}")
+	}
+	assertions, err := json.Marshal(t.Assertions)
+	if err != nil {
+		return err
+	}
+	if t.Assertions == nil {
+		assertions = []byte("[]")
+	}
+	steps, err := json.Marshal(t.Steps)
+	if err != nil {
+		return err
+	}
+	if t.Steps == nil {
+		steps = []byte("[]")
 	}
-	_, err := s.Db.Exec("INSERT INTO urls (url, probe_mode, thread_count, last_checked) VALUES (?, ?, ?, ?)", url, mode, threadCount, time.Now())
+	_, err = s.Db.Exec(`INSERT INTO urls
+		(url, probe_mode, thread_count, ping_count, probe_options, http_method, http_headers, http_body, http_body_type, http_assertions,
+			journey_steps, timeout_ms, max_redirects, accepted_status, keep_alive, proxy_url, ip_family, source_addr,
+			dns_server, resolve_ip, fan_out, client_cert_id, ca_bundle_id, tls_skip_verify, credential_id, last_checked)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		t.URL, t.ProbeMode, t.ThreadCount, t.PingCount, string(options), t.HTTPMethod, t.HTTPHeaders, t.HTTPBody, t.HTTPBodyType,
+		string(assertions), string(steps), t.TimeoutMs, t.MaxRedirects, t.AcceptedStatus, t.KeepAlive, t.ProxyURL, t.IPFamily, t.SourceAddr,
+		t.DNSServer, t.ResolveIP, t.FanOut, t.ClientCertID, t.CABundleID, t.TLSSkipVerify, t.CredentialID, time.Now())
 	return err
 }
 
@@ -213,96 +667,583 @@ func (s *Store) UpdateProbeNetworkError(id int, latency int64, firstUpTime sql.N
 	return err
 }
 
-// --- FUNGSI PROBE HISTORY (Diperbarui) ---
+// SetDegraded mencatat apakah run terakhir target fan-out hanya gagal di sebagian alamat
+func (s *Store) SetDegraded(id int, degraded bool) error {
+	_, err := s.Db.Exec("UPDATE urls SET degraded = ? WHERE id = ?", degraded, id)
+	return err
+}
 
-// AddProbeHistory menyimpan satu log probe
-func (s *Store) AddProbeHistory(urlID int, latencyMs int64, statusCode int, status string, description string) error {
-	_, err := s.Db.Exec("INSERT INTO probe_history (url_id, latency_ms, timestamp, status_code, status, description) VALUES (?, ?, ?, ?, ?, ?)",
-		urlID, latencyMs, time.Now(), statusCode, status, description)
-	// Juga membersihkan history lama agar DB tidak penuh
-	// Simpan sampai 1.000.000 baris terbaru, sisanya dihapus
-	_, _ = s.Db.Exec("DELETE FROM probe_history WHERE id NOT IN (SELECT id FROM probe_history ORDER BY timestamp DESC LIMIT 1000000)")
+// SaveTLSInfo menyimpan (upsert) detail sertifikat terakhir untuk satu URL
+func (s *Store) SaveTLSInfo(urlID int, info models.TLSInfo) error {
+	_, err := s.Db.Exec(`
+		INSERT INTO tls_info (url_id, not_after, issuer, sans, chain_valid, tls_version, checked_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(url_id) DO UPDATE SET
+			not_after = excluded.not_after,
+			issuer = excluded.issuer,
+			sans = excluded.sans,
+			chain_valid = excluded.chain_valid,
+			tls_version = excluded.tls_version,
+			checked_at = excluded.checked_at`,
+		urlID, info.NotAfter, info.Issuer, strings.Join(info.SANs, ","), info.ChainValid, info.Version, info.CheckedAt)
 	return err
 }
 
-// DeleteProbeHistory membersihkan history saat URL dihapus
-func (s *Store) DeleteProbeHistory(urlID int) error {
-	_, err := s.Db.Exec("DELETE FROM probe_history WHERE url_id = ?", urlID)
+// --- FUNGSI CERTIFICATES ---
+
+// AddCertificate menyimpan sertifikat yang sudah divalidasi
+func (s *Store) AddCertificate(c models.Certificate) error {
+	_, err := s.Db.Exec(`INSERT INTO certificates (name, kind, cert_pem, key_pem, subject, not_after, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		c.Name, c.Kind, c.CertPEM, c.KeyPEM, c.Subject, c.NotAfter, time.Now())
 	return err
 }
 
-// GetProbeHistory mengambil N probe terakhir untuk SATU URL (untuk Dashboard)
-func (s *Store) GetProbeHistory(urlID int, limit int) ([]models.ProbeHistory, error) {
-	// Diperbarui: Menggunakan JOIN untuk mengambil urls.url
-	rows, err := s.Db.Query(`
-		SELECT h.url_id, u.url, h.latency_ms, h.timestamp, h.status_code, h.status, h.description
-		FROM probe_history h
-		JOIN urls u ON h.url_id = u.id
-		WHERE h.url_id = ? 
-		ORDER BY h.timestamp DESC 
-		LIMIT ?`, urlID, limit)
+// GetCertificates mengembalikan semua sertifikat (termasuk PEM, untuk scheduler)
+func (s *Store) GetCertificates() ([]models.Certificate, error) {
+	rows, err := s.Db.Query(`SELECT id, name, kind, cert_pem, key_pem, COALESCE(subject, ''), not_after, created_at
+		FROM certificates ORDER BY kind, name`)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var history []models.ProbeHistory
+	var certs []models.Certificate
 	for rows.Next() {
-		var h models.ProbeHistory
-		if err := rows.Scan(&h.URLID, &h.URL, &h.LatencyMs, &h.Timestamp, &h.StatusCode, &h.Status, &h.Description); err != nil {
+		var c models.Certificate
+		var notAfter, createdAt sql.NullTime
+		if err := rows.Scan(&c.ID, &c.Name, &c.Kind, &c.CertPEM, &c.KeyPEM, &c.Subject, &notAfter, &createdAt); err != nil {
 			return nil, err
 		}
-		history = append(history, h)
+		c.NotAfter = notAfter.Time
+		c.CreatedAt = createdAt.Time
+		certs = append(certs, c)
 	}
-	return history, nil
+	return certs, rows.Err()
 }
 
-// GetAllProbeHistory mengambil N probe terakhir dari SEMUA URL (untuk Scheduler)
-func (s *Store) GetAllProbeHistory(limit int) ([]models.ProbeHistory, error) {
-	rows, err := s.Db.Query(`
-        SELECT h.url_id, u.url, h.latency_ms, h.timestamp, h.status_code, h.status, h.description
-        FROM probe_history h
-        JOIN urls u ON h.url_id = u.id
-        ORDER BY h.timestamp DESC 
-        LIMIT ?`, limit)
+// DeleteCertificate menghapus sertifikat dan melepasnya dari target yang memakainya
+func (s *Store) DeleteCertificate(id int) error {
+	if _, err := s.Db.Exec("UPDATE urls SET client_cert_id = 0 WHERE client_cert_id = ?", id); err != nil {
+		return err
+	}
+	if _, err := s.Db.Exec("UPDATE urls SET ca_bundle_id = 0 WHERE ca_bundle_id = ?", id); err != nil {
+		return err
+	}
+	_, err := s.Db.Exec("DELETE FROM certificates WHERE id = ?", id)
+	return err
+}
+
+// --- FUNGSI CREDENTIALS ---
+
+// AddCredential mengenkripsi secret lalu menyimpan credential
+func (s *Store) AddCredential(c models.Credential) error {
+	secret, err := encryptSecret(s.secretKey, c.Secret)
+	if err != nil {
+		return err
+	}
+	_, err = s.Db.Exec(`INSERT INTO credentials (name, kind, username, header_name, token_url, scopes, secret, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		c.Name, c.Kind, c.Username, c.HeaderName, c.TokenURL, c.Scopes, secret, time.Now())
+	return err
+}
+
+// GetCredentials mengembalikan daftar credential TANPA secret (untuk UI)
+func (s *Store) GetCredentials() ([]models.Credential, error) {
+	return s.queryCredentials(false)
+}
+
+// GetCredentialsWithSecrets mengembalikan credential beserta secret yang sudah
+// didekripsi (untuk scheduler). Credential yang gagal didekripsi dilewati.
+func (s *Store) GetCredentialsWithSecrets() ([]models.Credential, error) {
+	return s.queryCredentials(true)
+}
+
+func (s *Store) queryCredentials(withSecrets bool) ([]models.Credential, error) {
+	rows, err := s.Db.Query(`SELECT id, name, kind, username, header_name, token_url, scopes, secret, created_at
+		FROM credentials ORDER BY name`)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	var creds []models.Credential
+	for rows.Next() {
+		var c models.Credential
+		var secret []byte
+		var createdAt sql.NullTime
+		if err := rows.Scan(&c.ID, &c.Name, &c.Kind, &c.Username, &c.HeaderName, &c.TokenURL, &c.Scopes, &secret, &createdAt); err != nil {
+			return nil, err
+		}
+		c.CreatedAt = createdAt.Time
+		if withSecrets {
+			if c.Secret, err = decryptSecret(s.secretKey, secret); err != nil {
+				log.Printf("Credential %d (%s) tidak bisa dipakai: %v", c.ID, c.Name, err)
+				continue
+			}
+		}
+		creds = append(creds, c)
+	}
+	return creds, rows.Err()
+}
+
+// DeleteCredential menghapus credential dan melepasnya dari target yang memakainya
+func (s *Store) DeleteCredential(id int) error {
+	if _, err := s.Db.Exec("UPDATE urls SET credential_id = 0 WHERE credential_id = ?", id); err != nil {
+		return err
+	}
+	_, err := s.Db.Exec("DELETE FROM credentials WHERE id = ?", id)
+	return err
+}
+
+// --- FUNGSI PROBE HISTORY (Diperbarui) ---
+
+// historySelect adalah kolom standar probe_history (JOIN urls) yang dibaca oleh scanProbeHistory
+const historySelect = `
+		SELECT h.id, h.url_id, u.url, h.latency_ms, h.timestamp, h.status_code, h.status, h.description,
+			COALESCE(h.rtt_min_ms, 0), COALESCE(h.rtt_avg_ms, 0), COALESCE(h.rtt_max_ms, 0),
+			COALESCE(h.jitter_ms, 0), COALESCE(h.packet_loss_pct, 0),
+			COALESCE(h.dns_ms, 0), COALESCE(h.connect_ms, 0), COALESCE(h.tls_ms, 0),
+			COALESCE(h.ttfb_ms, 0), COALESCE(h.transfer_ms, 0),
+			COALESCE(h.error_class, ''), COALESCE(h.error_message, ''),
+			COALESCE(h.ws_handshake_ms, 0), COALESCE(h.ws_round_trip_ms, 0)
+		FROM probe_history h
+		JOIN urls u ON h.url_id = u.id`
+
+// scanProbeHistory membaca hasil query historySelect
+func scanProbeHistory(rows *sql.Rows) ([]models.ProbeHistory, error) {
+	defer rows.Close()
+
 	var history []models.ProbeHistory
 	for rows.Next() {
 		var h models.ProbeHistory
-		if err := rows.Scan(&h.URLID, &h.URL, &h.LatencyMs, &h.Timestamp, &h.StatusCode, &h.Status, &h.Description); err != nil {
+		if err := rows.Scan(&h.ID, &h.URLID, &h.URL, &h.LatencyMs, &h.Timestamp, &h.StatusCode, &h.Status, &h.Description,
+			&h.RTTMinMs, &h.RTTAvgMs, &h.RTTMaxMs, &h.JitterMs, &h.PacketLossPct,
+			&h.DNSMs, &h.ConnectMs, &h.TLSMs, &h.TTFBMs, &h.TransferMs,
+			&h.ErrorClass, &h.ErrorMessage, &h.HandshakeMs, &h.RoundTripMs); err != nil {
 			return nil, err
 		}
 		history = append(history, h)
 	}
-	return history, nil
+	return history, rows.Err()
 }
 
-// GetAllProbeHistoryPaged mengambil probe_history dengan limit dan offset (untuk pagination)
-func (s *Store) GetAllProbeHistoryPaged(limit int, offset int) ([]models.ProbeHistory, error) {
-	rows, err := s.Db.Query(`
-        SELECT h.url_id, u.url, h.latency_ms, h.timestamp, h.status_code, h.status, h.description
-        FROM probe_history h
-        JOIN urls u ON h.url_id = u.id
-        ORDER BY h.timestamp DESC
-        LIMIT ? OFFSET ?`, limit, offset)
+// AddProbeHistory menyimpan satu log probe (Timestamp diisi waktu sekarang)
+// dan mengembalikan id barisnya
+func (s *Store) AddProbeHistory(h models.ProbeHistory) (int64, error) {
+	res, err := s.Db.Exec(`INSERT INTO probe_history
+		(url_id, latency_ms, timestamp, status_code, status, description, rtt_min_ms, rtt_avg_ms, rtt_max_ms, jitter_ms, packet_loss_pct,
+			dns_ms, connect_ms, tls_ms, ttfb_ms, transfer_ms, error_class, error_message, ws_handshake_ms, ws_round_trip_ms)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		h.URLID, h.LatencyMs, time.Now(), h.StatusCode, h.Status, h.Description,
+		h.RTTMinMs, h.RTTAvgMs, h.RTTMaxMs, h.JitterMs, h.PacketLossPct,
+		h.DNSMs, h.ConnectMs, h.TLSMs, h.TTFBMs, h.TransferMs, h.ErrorClass, h.ErrorMessage,
+		h.HandshakeMs, h.RoundTripMs)
+	if err != nil {
+		return 0, err
+	}
+	// Juga membersihkan history lama agar DB tidak penuh
+	// Simpan sampai 1.000.000 baris terbaru, sisanya dihapus
+	_ = s.trimProbeHistory(maxProbeHistory)
+	return res.LastInsertId()
+}
+
+const maxProbeHistory = 1000000
+
+// historyChildTables adalah tabel yang barisnya milik satu baris probe_history
+// (kolom history_id) dan ikut dihapus saat history dipangkas
+var historyChildTables = []string{"probe_steps", "probe_addresses"}
+
+// trimProbeHistory menghapus history di luar keep baris terbaru beserta baris
+// anaknya. Hanya id yang dipangkas yang disentuh, jadi tabel anak tidak dipindai.
+func (s *Store) trimProbeHistory(keep int) error {
+	rows, err := s.Db.Query("SELECT id FROM probe_history ORDER BY timestamp DESC LIMIT -1 OFFSET ?", keep)
+	if err != nil {
+		return err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(ids) == 0 {
+		return err
+	}
+
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		for _, table := range historyChildTables {
+			if _, err := tx.Exec("DELETE FROM "+table+" WHERE history_id = ?", id); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+		if _, err := tx.Exec("DELETE FROM probe_history WHERE id = ?", id); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// AddProbeSteps menyimpan hasil per langkah journey untuk satu baris history
+func (s *Store) AddProbeSteps(historyID int64, steps []models.StepResult) error {
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, st := range steps {
+		_, err = tx.Exec(`INSERT INTO probe_steps (history_id, step_index, name, status_code, latency_ms, passed, error)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			historyID, st.Index, st.Name, st.StatusCode, st.LatencyMs, st.Passed, st.Error)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// AttachProbeSteps mengisi Steps untuk baris history yang punya hasil langkah (mode journey)
+func (s *Store) AttachProbeSteps(history []models.ProbeHistory) error {
+	if len(history) == 0 {
+		return nil
+	}
+	index := make(map[int64]int, len(history))
+	placeholders := make([]string, 0, len(history))
+	args := make([]any, 0, len(history))
+	for i, h := range history {
+		index[h.ID] = i
+		placeholders = append(placeholders, "?")
+		args = append(args, h.ID)
+	}
+
+	rows, err := s.Db.Query(`SELECT history_id, step_index, COALESCE(name, ''), COALESCE(status_code, 0),
+			COALESCE(latency_ms, 0), COALESCE(passed, 0), COALESCE(error, '')
+		FROM probe_steps
+		WHERE history_id IN (`+strings.Join(placeholders, ",")+`)
+		ORDER BY history_id, step_index`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var historyID int64
+		var st models.StepResult
+		if err := rows.Scan(&historyID, &st.Index, &st.Name, &st.StatusCode, &st.LatencyMs, &st.Passed, &st.Error); err != nil {
+			return err
+		}
+		if i, ok := index[historyID]; ok {
+			history[i].Steps = append(history[i].Steps, st)
+		}
+	}
+	return rows.Err()
+}
+
+// AddProbeAddresses menyimpan hasil per alamat IP target fan-out untuk satu baris history
+func (s *Store) AddProbeAddresses(historyID int64, addresses []models.AddressResult) error {
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, a := range addresses {
+		_, err = tx.Exec(`INSERT INTO probe_addresses (history_id, ip, status_code, latency_ms, passed, error)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			historyID, a.IP, a.StatusCode, a.LatencyMs, a.Passed, a.Error)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// AttachProbeAddresses mengisi Addresses untuk baris history target fan-out
+func (s *Store) AttachProbeAddresses(history []models.ProbeHistory) error {
+	if len(history) == 0 {
+		return nil
+	}
+	index := make(map[int64]int, len(history))
+	placeholders := make([]string, 0, len(history))
+	args := make([]any, 0, len(history))
+	for i, h := range history {
+		index[h.ID] = i
+		placeholders = append(placeholders, "?")
+		args = append(args, h.ID)
+	}
+
+	rows, err := s.Db.Query(`SELECT history_id, ip, COALESCE(status_code, 0), COALESCE(latency_ms, 0),
+			COALESCE(passed, 0), COALESCE(error, '')
+		FROM probe_addresses
+		WHERE history_id IN (`+strings.Join(placeholders, ",")+`)
+		ORDER BY history_id, id`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var historyID int64
+		var a models.AddressResult
+		if err := rows.Scan(&historyID, &a.IP, &a.StatusCode, &a.LatencyMs, &a.Passed, &a.Error); err != nil {
+			return err
+		}
+		if i, ok := index[historyID]; ok {
+			history[i].Addresses = append(history[i].Addresses, a)
+		}
+	}
+	return rows.Err()
+}
+
+// DeleteProbeHistory membersihkan history (dan hasil langkah journey / per alamat, traceroute,
+// perubahan konten) saat URL dihapus
+func (s *Store) DeleteProbeHistory(urlID int) error {
+	_, err := s.Db.Exec("DELETE FROM probe_steps WHERE history_id IN (SELECT id FROM probe_history WHERE url_id = ?)", urlID)
+	if err != nil {
+		return err
+	}
+	_, err = s.Db.Exec("DELETE FROM probe_addresses WHERE history_id IN (SELECT id FROM probe_history WHERE url_id = ?)", urlID)
+	if err != nil {
+		return err
+	}
+	_, err = s.Db.Exec("DELETE FROM traceroute_hops WHERE traceroute_id IN (SELECT id FROM traceroutes WHERE url_id = ?)", urlID)
+	if err != nil {
+		return err
+	}
+	_, err = s.Db.Exec("DELETE FROM traceroutes WHERE url_id = ?", urlID)
+	if err != nil {
+		return err
+	}
+	_, err = s.Db.Exec("DELETE FROM content_changes WHERE url_id = ?", urlID)
+	if err != nil {
+		return err
+	}
+	_, err = s.Db.Exec("DELETE FROM content_snapshots WHERE url_id = ?", urlID)
+	if err != nil {
+		return err
+	}
+	_, err = s.Db.Exec("DELETE FROM probe_history WHERE url_id = ?", urlID)
+	return err
+}
+
+// GetContentSnapshot mengambil konten body terakhir URL yang dipantau
+// (Hash kosong jika belum ada)
+func (s *Store) GetContentSnapshot(urlID int) (models.ContentSnapshot, error) {
+	var snap models.ContentSnapshot
+	err := s.Db.QueryRow("SELECT hash, COALESCE(content, ''), truncated, updated_at FROM content_snapshots WHERE url_id = ?", urlID).
+		Scan(&snap.Hash, &snap.Content, &snap.Truncated, &snap.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return models.ContentSnapshot{}, nil
+	}
+	return snap, err
+}
+
+// SaveContentSnapshot menyimpan (upsert) konten body terakhir untuk satu URL
+func (s *Store) SaveContentSnapshot(urlID int, snap models.ContentSnapshot) error {
+	_, err := s.Db.Exec(`
+		INSERT INTO content_snapshots (url_id, hash, content, truncated, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(url_id) DO UPDATE SET
+			hash = excluded.hash,
+			content = excluded.content,
+			truncated = excluded.truncated,
+			updated_at = excluded.updated_at`,
+		urlID, snap.Hash, snap.Content, snap.Truncated, time.Now())
+	return err
+}
+
+// maxContentChangesPerURL adalah jumlah perubahan konten terbaru yang disimpan per URL
+const maxContentChangesPerURL = 100
+
+// AddContentChange mencatat satu perubahan konten. Perubahan lama di luar
+// maxContentChangesPerURL terbaru untuk URL yang sama dihapus.
+func (s *Store) AddContentChange(c models.ContentChange) error {
+	_, err := s.Db.Exec(`INSERT INTO content_changes (url_id, history_id, timestamp, old_hash, new_hash, added, removed, diff)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		c.URLID, c.HistoryID, time.Now(), c.OldHash, c.NewHash, c.Added, c.Removed, c.Diff)
+	if err != nil {
+		return err
+	}
+	_, _ = s.Db.Exec(`DELETE FROM content_changes WHERE url_id = ? AND id NOT IN
+		(SELECT id FROM content_changes WHERE url_id = ? ORDER BY id DESC LIMIT ?)`, c.URLID, c.URLID, maxContentChangesPerURL)
+	return nil
+}
+
+// GetContentChanges mengambil N perubahan konten terakhir untuk satu URL
+func (s *Store) GetContentChanges(urlID int, limit int) ([]models.ContentChange, error) {
+	rows, err := s.Db.Query(`SELECT id, url_id, COALESCE(history_id, 0), timestamp, COALESCE(old_hash, ''), COALESCE(new_hash, ''),
+			COALESCE(added, 0), COALESCE(removed, 0), COALESCE(diff, '')
+		FROM content_changes
+		WHERE url_id = ?
+		ORDER BY id DESC
+		LIMIT ?`, urlID, limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var history []models.ProbeHistory
+	var changes []models.ContentChange
 	for rows.Next() {
-		var h models.ProbeHistory
-		if err := rows.Scan(&h.URLID, &h.URL, &h.LatencyMs, &h.Timestamp, &h.StatusCode, &h.Status, &h.Description); err != nil {
+		var c models.ContentChange
+		if err := rows.Scan(&c.ID, &c.URLID, &c.HistoryID, &c.Timestamp, &c.OldHash, &c.NewHash,
+			&c.Added, &c.Removed, &c.Diff); err != nil {
 			return nil, err
 		}
-		history = append(history, h)
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// maxTraceroutesPerURL adalah jumlah traceroute insiden terbaru yang disimpan per URL
+const maxTraceroutesPerURL = 50
+
+// AddTraceroute menyimpan satu hasil traceroute beserta hop-hopnya. Traceroute
+// lama di luar maxTraceroutesPerURL terbaru untuk URL yang sama dihapus.
+func (s *Store) AddTraceroute(t models.Traceroute) error {
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return err
+	}
+	res, err := tx.Exec(`INSERT INTO traceroutes (url_id, history_id, timestamp, method, destination, reached, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		t.URLID, t.HistoryID, time.Now(), t.Method, t.Destination, t.Reached, t.Error)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, hop := range t.Hops {
+		_, err = tx.Exec(`INSERT INTO traceroute_hops (traceroute_id, ttl, ip, rtt_ms, reached) VALUES (?, ?, ?, ?, ?)`,
+			id, hop.TTL, hop.IP, hop.RTTMs, hop.Reached)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// Hop hanya dihapus untuk traceroute yang dipangkas
+	rows, err := s.Db.Query("SELECT id FROM traceroutes WHERE url_id = ? ORDER BY id DESC LIMIT -1 OFFSET ?", t.URLID, maxTraceroutesPerURL)
+	if err != nil {
+		return nil
+	}
+	var stale []int64
+	for rows.Next() {
+		var staleID int64
+		if rows.Scan(&staleID) == nil {
+			stale = append(stale, staleID)
+		}
+	}
+	rows.Close()
+	for _, staleID := range stale {
+		_, _ = s.Db.Exec("DELETE FROM traceroute_hops WHERE traceroute_id = ?", staleID)
+		_, _ = s.Db.Exec("DELETE FROM traceroutes WHERE id = ?", staleID)
+	}
+	return nil
+}
+
+// GetTraceroutes mengambil N traceroute insiden terakhir untuk satu URL,
+// lengkap dengan hop dan deskripsi insidennya
+func (s *Store) GetTraceroutes(urlID int, limit int) ([]models.Traceroute, error) {
+	rows, err := s.Db.Query(`SELECT t.id, t.url_id, COALESCE(t.history_id, 0), t.timestamp, COALESCE(h.description, ''),
+			COALESCE(t.method, ''), COALESCE(t.destination, ''), COALESCE(t.reached, 0), COALESCE(t.error, '')
+		FROM traceroutes t
+		LEFT JOIN probe_history h ON h.id = t.history_id
+		WHERE t.url_id = ?
+		ORDER BY t.id DESC
+		LIMIT ?`, urlID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var traces []models.Traceroute
+	index := map[int64]int{}
+	for rows.Next() {
+		var t models.Traceroute
+		if err := rows.Scan(&t.ID, &t.URLID, &t.HistoryID, &t.Timestamp, &t.Incident,
+			&t.Method, &t.Destination, &t.Reached, &t.Error); err != nil {
+			return nil, err
+		}
+		index[t.ID] = len(traces)
+		traces = append(traces, t)
+	}
+	if err := rows.Err(); err != nil || len(traces) == 0 {
+		return traces, err
+	}
+
+	placeholders := make([]string, 0, len(traces))
+	args := make([]any, 0, len(traces))
+	for _, t := range traces {
+		placeholders = append(placeholders, "?")
+		args = append(args, t.ID)
+	}
+	hopRows, err := s.Db.Query(`SELECT traceroute_id, ttl, COALESCE(ip, ''), COALESCE(rtt_ms, 0), COALESCE(reached, 0)
+		FROM traceroute_hops
+		WHERE traceroute_id IN (`+strings.Join(placeholders, ",")+`)
+		ORDER BY traceroute_id, ttl`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer hopRows.Close()
+	for hopRows.Next() {
+		var traceID int64
+		var hop models.TraceHop
+		if err := hopRows.Scan(&traceID, &hop.TTL, &hop.IP, &hop.RTTMs, &hop.Reached); err != nil {
+			return nil, err
+		}
+		if i, ok := index[traceID]; ok {
+			traces[i].Hops = append(traces[i].Hops, hop)
+		}
+	}
+	return traces, hopRows.Err()
+}
+
+// GetProbeHistory mengambil N probe terakhir untuk SATU URL (untuk Dashboard)
+func (s *Store) GetProbeHistory(urlID int, limit int) ([]models.ProbeHistory, error) {
+	rows, err := s.Db.Query(historySelect+`
+		WHERE h.url_id = ? 
+		ORDER BY h.timestamp DESC 
+		LIMIT ?`, urlID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanProbeHistory(rows)
+}
+
+// GetAllProbeHistory mengambil N probe terakhir dari SEMUA URL (untuk Scheduler)
+func (s *Store) GetAllProbeHistory(limit int) ([]models.ProbeHistory, error) {
+	rows, err := s.Db.Query(historySelect+`
+        ORDER BY h.timestamp DESC 
+        LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
 	}
-	return history, nil
+	return scanProbeHistory(rows)
+}
+
+// GetAllProbeHistoryPaged mengambil probe_history dengan limit dan offset (untuk pagination)
+func (s *Store) GetAllProbeHistoryPaged(limit int, offset int) ([]models.ProbeHistory, error) {
+	rows, err := s.Db.Query(historySelect+`
+        ORDER BY h.timestamp DESC
+        LIMIT ? OFFSET ?`, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return scanProbeHistory(rows)
 }
 
 // CountProbeHistory menghitung total baris probe_history
@@ -321,49 +1262,23 @@ func (s *Store) CountProbeHistoryByRange(since time.Time) (int64, error) {
 
 // GetAllProbeHistoryByRangePaged mengambil probe_history sejak waktu tertentu (semua URL), paged
 func (s *Store) GetAllProbeHistoryByRangePaged(since time.Time, limit int, offset int) ([]models.ProbeHistory, error) {
-	rows, err := s.Db.Query(`
-        SELECT h.url_id, u.url, h.latency_ms, h.timestamp, h.status_code, h.status, h.description
-        FROM probe_history h
-        JOIN urls u ON h.url_id = u.id
+	rows, err := s.Db.Query(historySelect+`
         WHERE h.timestamp >= ?
         ORDER BY h.timestamp DESC
         LIMIT ? OFFSET ?`, since, limit, offset)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var history []models.ProbeHistory
-	for rows.Next() {
-		var h models.ProbeHistory
-		if err := rows.Scan(&h.URLID, &h.URL, &h.LatencyMs, &h.Timestamp, &h.StatusCode, &h.Status, &h.Description); err != nil {
-			return nil, err
-		}
-		history = append(history, h)
-	}
-	return history, nil
+	return scanProbeHistory(rows)
 }
 
 // GetProbeHistoryByRange mengambil probe untuk SATU URL dalam interval waktu tertentu (ASC)
 func (s *Store) GetProbeHistoryByRange(urlID int, since time.Time) ([]models.ProbeHistory, error) {
-	rows, err := s.Db.Query(`
-		SELECT h.url_id, u.url, h.latency_ms, h.timestamp, h.status_code, h.status, h.description
-		FROM probe_history h
-		JOIN urls u ON h.url_id = u.id
+	rows, err := s.Db.Query(historySelect+`
 		WHERE h.url_id = ? AND h.timestamp >= ?
 		ORDER BY h.timestamp ASC`, urlID, since)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var history []models.ProbeHistory
-	for rows.Next() {
-		var h models.ProbeHistory
-		if err := rows.Scan(&h.URLID, &h.URL, &h.LatencyMs, &h.Timestamp, &h.StatusCode, &h.Status, &h.Description); err != nil {
-			return nil, err
-		}
-		history = append(history, h)
-	}
-	return history, nil
+	return scanProbeHistory(rows)
 }
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"os"
+)
+
+// SecretKeyEnv adalah environment variable berisi kunci enkripsi credential.
+// Nilainya bebas (passphrase); kunci AES-256 diturunkan dengan SHA-256.
+const SecretKeyEnv = "PROBE_SECRET_KEY"
+
+// ErrNoSecretKey dikembalikan jika credential dipakai tanpa kunci enkripsi
+var ErrNoSecretKey = errors.New(SecretKeyEnv + " is not set")
+
+// secretKeyFromEnv menurunkan kunci enkripsi dari environment (nil jika tidak diset)
+func secretKeyFromEnv() []byte {
+	passphrase := os.Getenv(SecretKeyEnv)
+	if passphrase == "" {
+		return nil
+	}
+	key := sha256.Sum256([]byte(passphrase))
+	return key[:]
+}
+
+// encryptSecret mengenkripsi secret dengan AES-GCM; hasilnya nonce + ciphertext
+func encryptSecret(key []byte, secret string) ([]byte, error) {
+	if key == nil {
+		return nil, ErrNoSecretKey
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, []byte(secret), nil), nil
+}
+
+// decryptSecret membuka hasil encryptSecret
+func decryptSecret(key []byte, data []byte) (string, error) {
+	if key == nil {
+		return "", ErrNoSecretKey
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("encrypted secret is too short")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.New("cannot decrypt secret (wrong " + SecretKeyEnv + "?)")
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
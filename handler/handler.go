@@ -2,13 +2,16 @@ package handler
 
 import (
 	"encoding/json"
+	"fmt"
 	"html/template"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
 	"strings"
 	"test/database"
 	"test/models"
+	"test/probe"
 	"test/scheduler"
 	"time"
 
@@ -78,6 +81,12 @@ func (h *Handlers) SchedulerHistoryAPI(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error":"failed to get history"}`, http.StatusInternalServerError)
 		return
 	}
+	if err := h.App.Store.AttachProbeSteps(history); err != nil {
+		log.Printf("SchedulerHistoryAPI: gagal mengambil hasil langkah: %v", err)
+	}
+	if err := h.App.Store.AttachProbeAddresses(history); err != nil {
+		log.Printf("SchedulerHistoryAPI: gagal mengambil hasil per alamat: %v", err)
+	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(history)
 }
@@ -91,18 +100,33 @@ func (h *Handlers) URLsAPI(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	type tlsDTO struct {
+		NotAfter   time.Time `json:"NotAfter"`
+		DaysLeft   int       `json:"DaysLeft"`
+		Issuer     string    `json:"Issuer"`
+		SANs       []string  `json:"SANs"`
+		ChainValid bool      `json:"ChainValid"`
+		Version    string    `json:"Version"`
+	}
+
 	type urlDTO struct {
 		ID              int       `json:"ID"`
 		URL             string    `json:"URL"`
 		ProbeMode       string    `json:"ProbeMode"`
 		ThreadCount     int       `json:"ThreadCount"`
+		PingCount       int       `json:"PingCount"`
 		LastStatus      int       `json:"LastStatus"`
 		LastLatencyMs   int64     `json:"LastLatencyMs"`
 		LastChecked     time.Time `json:"LastChecked"`
 		IsUp            bool      `json:"IsUp"`
+		Degraded        bool      `json:"Degraded,omitempty"`
 		TotalProbeCount int64     `json:"TotalProbeCount"`
 		TotalLatencySum int64     `json:"TotalLatencySum"`
 		Uptime          string    `json:"Uptime"`
+		Proxy           string    `json:"Proxy,omitempty"`
+		Network         string    `json:"Network,omitempty"`
+		TLSSkipVerify   bool      `json:"TLSSkipVerify,omitempty"`
+		TLS             *tlsDTO   `json:"TLS,omitempty"`
 	}
 
 	out := make([]urlDTO, 0, len(urls))
@@ -113,14 +137,29 @@ func (h *Handlers) URLsAPI(w http.ResponseWriter, r *http.Request) {
 			URL:             u.URL,
 			ProbeMode:       u.ProbeMode,
 			ThreadCount:     u.ThreadCount,
+			PingCount:       u.PingCount,
 			LastStatus:      u.LastStatus,
 			LastLatencyMs:   u.LastLatencyMs,
 			LastChecked:     u.LastChecked,
 			IsUp:            u.IsUp,
+			Degraded:        u.IsDegraded(),
 			TotalProbeCount: u.TotalProbeCount,
 			TotalLatencySum: u.TotalLatencySum,
 			Uptime:          u.GetUptime(),
+			Proxy:           u.ProxyLabel(),
+			Network:         u.NetworkLabel(),
+			TLSSkipVerify:   u.TLSSkipVerify,
 		})
+		if u.TLS != nil {
+			out[len(out)-1].TLS = &tlsDTO{
+				NotAfter:   u.TLS.NotAfter,
+				DaysLeft:   u.TLS.DaysLeft(),
+				Issuer:     u.TLS.Issuer,
+				SANs:       u.TLS.SANs,
+				ChainValid: u.TLS.ChainValid,
+				Version:    u.TLS.Version,
+			}
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -191,6 +230,24 @@ func (h *Handlers) DashboardPage(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Traceroute yang diambil saat URL terpilih berubah Up -> Down
+	var traceroutes []models.Traceroute
+	if selectedID > 0 {
+		traceroutes, err = h.App.Store.GetTraceroutes(selectedID, dashboardTraceroutes)
+		if err != nil {
+			log.Printf("Gagal mengambil traceroute: %v", err)
+		}
+	}
+
+	// Perubahan konten body URL terpilih (opsi content_watch)
+	var contentChanges []models.ContentChange
+	if selectedID > 0 {
+		contentChanges, err = h.App.Store.GetContentChanges(selectedID, dashboardContentChanges)
+		if err != nil {
+			log.Printf("Gagal mengambil perubahan konten: %v", err)
+		}
+	}
+
 	// Siapkan PageData untuk dikirim ke template
 	urlActive := 0
 	for _, u := range urls {
@@ -219,6 +276,8 @@ func (h *Handlers) DashboardPage(w http.ResponseWriter, r *http.Request) {
 		PageNumber:       1,
 		PageSize:         len(historyData),
 		GlobalUptimePct:  uptimePerc,
+		Traceroutes:      traceroutes,
+		ContentChanges:   contentChanges,
 	}
 
 	// Render template DASHBOARD
@@ -236,6 +295,67 @@ func (h *Handlers) DashboardPage(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// urlsPageData menambahkan daftar mode probe (dari registry) ke PageData
+type urlsPageData struct {
+	models.PageData
+	Modes        []modeForm
+	Certificates []models.Certificate
+	Credentials  []models.Credential // tanpa secret
+}
+
+// modeForm adalah satu mode probe beserta baris-baris field form-nya
+type modeForm struct {
+	Name  string
+	Label string
+	Rows  [][]probe.Field
+}
+
+// modeForms membangun pilihan mode dan field form dari registry prober.
+// Field dengan ChoicesFrom diisi pilihan credential atau sertifikat sesuai jenisnya.
+func modeForms(certs []models.Certificate, creds []models.Credential) []modeForm {
+	var forms []modeForm
+	for _, p := range probe.Probers() {
+		rows := probe.FormRows(p)
+		for _, row := range rows {
+			for i, f := range row {
+				switch f.ChoicesFrom {
+				case "":
+				case "credential":
+					row[i].Choices = credentialChoices(creds)
+				default:
+					row[i].Choices = certificateChoices(certs, f.ChoicesFrom)
+				}
+			}
+		}
+		forms = append(forms, modeForm{Name: p.Name(), Label: p.Label(), Rows: rows})
+	}
+	return forms
+}
+
+// credentialChoices membuat pilihan select credential (nama dan jenis saja)
+func credentialChoices(creds []models.Credential) []probe.Choice {
+	choices := []probe.Choice{{Value: "", Label: "Tanpa autentikasi"}}
+	for _, c := range creds {
+		choices = append(choices, probe.Choice{Value: strconv.Itoa(c.ID), Label: c.Name + " (" + c.Kind + ")"})
+	}
+	return choices
+}
+
+// certificateChoices membuat pilihan select untuk sertifikat satu jenis
+func certificateChoices(certs []models.Certificate, kind string) []probe.Choice {
+	label := "Tanpa client certificate"
+	if kind == models.CertKindCA {
+		label = "CA sistem"
+	}
+	choices := []probe.Choice{{Value: "", Label: label}}
+	for _, c := range certs {
+		if c.Kind == kind {
+			choices = append(choices, probe.Choice{Value: strconv.Itoa(c.ID), Label: c.Name})
+		}
+	}
+	return choices
+}
+
 // URLsPage menangani halaman '/urls'
 func (h *Handlers) URLsPage(w http.ResponseWriter, r *http.Request) {
 	urls, err := h.App.Store.GetAllURLs()
@@ -245,10 +365,24 @@ func (h *Handlers) URLsPage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	data := models.PageData{
-		Page:            "urls",
-		URLs:            urls,
-		LastCheckedTime: getLatestProbeTime(urls),
+	certs, err := h.App.Store.GetCertificates()
+	if err != nil {
+		log.Printf("Gagal mengambil sertifikat: %v", err)
+	}
+	creds, err := h.App.Store.GetCredentials()
+	if err != nil {
+		log.Printf("Gagal mengambil credential: %v", err)
+	}
+
+	data := urlsPageData{
+		PageData: models.PageData{
+			Page:            "urls",
+			URLs:            urls,
+			LastCheckedTime: getLatestProbeTime(urls),
+		},
+		Modes:        modeForms(certs, creds),
+		Certificates: certs,
+		Credentials:  creds,
 	}
 
 	// Render template URLS (parse spesifik agar konten sesuai halaman)
@@ -282,6 +416,11 @@ func (h *Handlers) SchedulerPage(w http.ResponseWriter, r *http.Request) {
 		schedulerThreadCount = 1
 	}
 
+	proxyURL, err := h.App.Store.GetProxyURL()
+	if err != nil {
+		log.Printf("Gagal mengambil proxy global: %v", err)
+	}
+
 	// Ambil semua URL untuk last checked time
 	urls, _ := h.App.Store.GetAllURLs()
 
@@ -336,6 +475,12 @@ func (h *Handlers) SchedulerPage(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.Printf("Gagal mengambil semua history: %v", err)
 	}
+	if err := h.App.Store.AttachProbeSteps(historyData); err != nil {
+		log.Printf("Gagal mengambil hasil langkah journey: %v", err)
+	}
+	if err := h.App.Store.AttachProbeAddresses(historyData); err != nil {
+		log.Printf("Gagal mengambil hasil per alamat: %v", err)
+	}
 	totalPages := 0
 	if pageSize > 0 {
 		totalPages = int((totalItems + int64(pageSize) - 1) / int64(pageSize))
@@ -365,6 +510,7 @@ func (h *Handlers) SchedulerPage(w http.ResponseWriter, r *http.Request) {
 		Page:                 "scheduler",
 		CurrentInterval:      interval,
 		SchedulerThreadCount: schedulerThreadCount,
+		ProxyURL:             models.RedactURL(proxyURL),
 		LastCheckedTime:      getLatestProbeTime(urls),
 		HistoryData:          historyData,
 		PageNumber:           pageNum,
@@ -400,9 +546,9 @@ func (h *Handlers) AddURL(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/urls", http.StatusSeeOther)
 		return
 	}
-	mode := r.FormValue("mode")
-	if mode != "tcp" && mode != "icmp" {
-		mode = "http"
+	prober, ok := probe.Get(r.FormValue("mode"))
+	if !ok {
+		prober = probe.Lookup(probe.DefaultMode)
 	}
 
 	// Parse thread count
@@ -414,17 +560,186 @@ func (h *Handlers) AddURL(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if mode == "http" && !((strings.HasPrefix(url, "http://")) || (strings.HasPrefix(url, "https://"))) {
-		url = "https://" + url
+	// Timeout per probe (ms), berlaku untuk semua mode
+	timeoutMs := int(models.DefaultTimeout / time.Millisecond)
+	if tm, err := strconv.Atoi(r.FormValue("timeout_ms")); err == nil && tm > 0 {
+		timeoutMs = tm
 	}
 
-	err := h.App.Store.AddURLWithMode(url, mode, threadCount)
+	target := models.TargetURL{
+		URL:         url,
+		ProbeMode:   prober.Name(),
+		ThreadCount: threadCount,
+		TimeoutMs:   timeoutMs,
+		Options:     parseProbeOptions(r),
+	}
+	// Field khusus mode dibaca dan divalidasi oleh prober-nya sendiri
+	if err := prober.Configure(&target, r.PostForm); err != nil {
+		log.Printf("Konfigurasi %s tidak valid untuk %s: %v", prober.Name(), url, err)
+		http.Redirect(w, r, "/urls", http.StatusSeeOther)
+		return
+	}
+	if err := h.checkCertificates(target); err != nil {
+		log.Printf("Sertifikat tidak valid untuk %s: %v", url, err)
+		http.Redirect(w, r, "/urls", http.StatusSeeOther)
+		return
+	}
+	if err := h.checkCredential(target); err != nil {
+		log.Printf("Credential tidak valid untuk %s: %v", url, err)
+		http.Redirect(w, r, "/urls", http.StatusSeeOther)
+		return
+	}
+
+	err := h.App.Store.AddTargetURL(target)
 	if err != nil {
 		log.Printf("Gagal menambah URL: %v", err)
 	}
 	http.Redirect(w, r, "/urls", http.StatusSeeOther)
 }
 
+// checkCertificates memastikan sertifikat yang dipilih target ada dan jenisnya benar
+func (h *Handlers) checkCertificates(target models.TargetURL) error {
+	if target.ClientCertID == 0 && target.CABundleID == 0 {
+		return nil
+	}
+	certs, err := h.App.Store.GetCertificates()
+	if err != nil {
+		return err
+	}
+	kinds := make(map[int]string, len(certs))
+	for _, c := range certs {
+		kinds[c.ID] = c.Kind
+	}
+	if target.ClientCertID != 0 && kinds[target.ClientCertID] != models.CertKindClient {
+		return fmt.Errorf("client certificate #%d not found", target.ClientCertID)
+	}
+	if target.CABundleID != 0 && kinds[target.CABundleID] != models.CertKindCA {
+		return fmt.Errorf("CA bundle #%d not found", target.CABundleID)
+	}
+	return nil
+}
+
+// checkCredential memastikan credential yang dipilih target ada
+func (h *Handlers) checkCredential(target models.TargetURL) error {
+	if target.CredentialID == 0 {
+		return nil
+	}
+	creds, err := h.App.Store.GetCredentials()
+	if err != nil {
+		return err
+	}
+	for _, c := range creds {
+		if c.ID == target.CredentialID {
+			return nil
+		}
+	}
+	return fmt.Errorf("credential #%d not found", target.CredentialID)
+}
+
+// AddCredential menangani form credential baru. Secret dienkripsi oleh Store
+// dan tidak pernah ditampilkan kembali.
+func (h *Handlers) AddCredential(w http.ResponseWriter, r *http.Request) {
+	cred := models.Credential{
+		Name:       strings.TrimSpace(r.FormValue("cred_name")),
+		Kind:       r.FormValue("cred_kind"),
+		Username:   strings.TrimSpace(r.FormValue("cred_username")),
+		HeaderName: strings.TrimSpace(r.FormValue("cred_header")),
+		TokenURL:   strings.TrimSpace(r.FormValue("cred_token_url")),
+		Scopes:     strings.TrimSpace(r.FormValue("cred_scopes")),
+		Secret:     r.FormValue("cred_secret"),
+	}
+	if cred.Kind != models.CredentialBasic && cred.Kind != models.CredentialOAuth2 {
+		cred.Username = ""
+	}
+	if cred.Kind != models.CredentialAPIKey {
+		cred.HeaderName = ""
+	}
+	if cred.Kind != models.CredentialOAuth2 {
+		cred.TokenURL, cred.Scopes = "", ""
+	}
+	if cred.Name == "" {
+		log.Println("Credential tidak valid: name is required")
+		http.Redirect(w, r, "/urls", http.StatusSeeOther)
+		return
+	}
+	if err := probe.ValidateCredential(cred); err != nil {
+		log.Printf("Credential %s tidak valid: %v", cred.Name, err)
+		http.Redirect(w, r, "/urls", http.StatusSeeOther)
+		return
+	}
+
+	if err := h.App.Store.AddCredential(cred); err != nil {
+		log.Printf("Gagal menyimpan credential %s: %v", cred.Name, err)
+	}
+	http.Redirect(w, r, "/urls", http.StatusSeeOther)
+}
+
+// DeleteCredential menangani link hapus credential
+func (h *Handlers) DeleteCredential(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID tidak valid", http.StatusBadRequest)
+		return
+	}
+	if err := h.App.Store.DeleteCredential(id); err != nil {
+		log.Printf("Gagal menghapus credential: %v", err)
+	}
+	http.Redirect(w, r, "/urls", http.StatusSeeOther)
+}
+
+// AddCertificate menangani upload client cert/key atau CA bundle (PEM)
+func (h *Handlers) AddCertificate(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(maxCertificateUpload); err != nil {
+		log.Printf("Upload sertifikat gagal: %v", err)
+		http.Redirect(w, r, "/urls", http.StatusSeeOther)
+		return
+	}
+	cert := models.Certificate{
+		Name: strings.TrimSpace(r.FormValue("cert_name")),
+		Kind: r.FormValue("cert_kind"),
+	}
+	var err error
+	if cert.CertPEM, err = readUpload(r, "cert_file"); err != nil {
+		log.Printf("Upload sertifikat gagal: %v", err)
+		http.Redirect(w, r, "/urls", http.StatusSeeOther)
+		return
+	}
+	if cert.Kind == models.CertKindClient {
+		if cert.KeyPEM, err = readUpload(r, "key_file"); err != nil {
+			log.Printf("Upload private key gagal: %v", err)
+			http.Redirect(w, r, "/urls", http.StatusSeeOther)
+			return
+		}
+	}
+	cert.Subject, cert.NotAfter, err = probe.ParseCertificate(cert.Kind, cert.CertPEM, cert.KeyPEM)
+	if err != nil {
+		log.Printf("Sertifikat tidak valid: %v", err)
+		http.Redirect(w, r, "/urls", http.StatusSeeOther)
+		return
+	}
+	if cert.Name == "" {
+		cert.Name = cert.Subject
+	}
+
+	if err := h.App.Store.AddCertificate(cert); err != nil {
+		log.Printf("Gagal menyimpan sertifikat: %v", err)
+	}
+	http.Redirect(w, r, "/urls", http.StatusSeeOther)
+}
+
+// DeleteCertificate menangani link hapus sertifikat
+func (h *Handlers) DeleteCertificate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID tidak valid", http.StatusBadRequest)
+		return
+	}
+	if err := h.App.Store.DeleteCertificate(id); err != nil {
+		log.Printf("Gagal menghapus sertifikat: %v", err)
+	}
+	http.Redirect(w, r, "/urls", http.StatusSeeOther)
+}
+
 // DeleteURL menangani link 'Hapus'
 func (h *Handlers) DeleteURL(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -443,9 +758,28 @@ func (h *Handlers) DeleteURL(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.Printf("Gagal menghapus URL: %v", err)
 	}
+	h.pruneProxyTransports()
 	http.Redirect(w, r, "/urls", http.StatusSeeOther)
 }
 
+// pruneProxyTransports membuang transport HTTP untuk proxy yang tidak lagi
+// dipakai target mana pun (proxy target, atau proxy global jika kosong)
+func (h *Handlers) pruneProxyTransports() {
+	urls, err := h.App.Store.GetAllURLs()
+	if err != nil {
+		log.Println("Failed to load URLs for proxy cleanup:", err)
+		return
+	}
+	globalProxy, _ := h.App.Store.GetProxyURL()
+	proxies := []string{globalProxy}
+	for _, u := range urls {
+		if u.ProxyURL != "" {
+			proxies = append(proxies, u.ProxyURL)
+		}
+	}
+	probe.PruneProxyTransports(proxies)
+}
+
 // UpdateSettings menangani form 'Simpan Jadwal'
 func (h *Handlers) UpdateSettings(w http.ResponseWriter, r *http.Request) {
 	interval := r.FormValue("interval")
@@ -475,6 +809,19 @@ func (h *Handlers) UpdateSettings(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Proxy global; form menampilkan versi tanpa password, jadi nilai yang
+	// sama dengan versi itu berarti proxy tidak diubah
+	proxyURL := strings.TrimSpace(r.FormValue("proxy_url"))
+	currentProxy, _ := h.App.Store.GetProxyURL()
+	if proxyURL == models.RedactURL(currentProxy) {
+		proxyURL = currentProxy
+	}
+	if _, err := probe.ParseProxyURL(proxyURL); err != nil {
+		log.Println("Invalid proxy:", err)
+		http.Redirect(w, r, "/scheduler", http.StatusSeeOther)
+		return
+	}
+
 	err := h.App.Store.SetScheduleInterval(interval)
 	if err != nil {
 		log.Println("Failed to save interval:", err)
@@ -482,6 +829,12 @@ func (h *Handlers) UpdateSettings(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = h.App.Store.SetProxyURL(proxyURL)
+	if err != nil {
+		log.Println("Failed to save proxy:", err)
+	}
+	h.pruneProxyTransports()
+
 	err = h.App.Store.SetSchedulerThreadCount(schedulerThreadCount)
 	if err != nil {
 		log.Println("Failed to save scheduler thread count:", err)
@@ -501,6 +854,23 @@ func (h *Handlers) UpdateSettings(w http.ResponseWriter, r *http.Request) {
 
 // === FUNCTION HELPER ===
 
+// maxCertificateUpload membatasi ukuran upload sertifikat (PEM)
+const maxCertificateUpload = 1 << 20
+
+// readUpload membaca isi file upload; field kosong menghasilkan error
+func readUpload(r *http.Request, field string) (string, error) {
+	file, _, err := r.FormFile(field)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", field, err)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxCertificateUpload))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
 // calculateGlobalAvgLatency menghitung rata-rata dari semua URL
 func calculateGlobalAvgLatency(urls []models.TargetURL) int64 {
 	var totalSum, totalCount int64
@@ -557,6 +927,73 @@ func (h *Handlers) ChartAPI(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(historyData)
 }
 
+// Jumlah traceroute insiden dan perubahan konten yang ditampilkan di dashboard
+const (
+	dashboardTraceroutes    = 5
+	dashboardContentChanges = 5
+)
+
+// TracerouteAPI mengembalikan traceroute insiden terakhir untuk satu URL
+func (h *Handlers) TracerouteAPI(w http.ResponseWriter, r *http.Request) {
+	urlID, _ := strconv.Atoi(r.URL.Query().Get("url_id"))
+	if urlID <= 0 {
+		http.Error(w, `{"error":"url_id required"}`, http.StatusBadRequest)
+		return
+	}
+	limit := dashboardTraceroutes
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if n, convErr := strconv.Atoi(v); convErr == nil && n > 0 && n <= 50 {
+			limit = n
+		}
+	}
+	traces, err := h.App.Store.GetTraceroutes(urlID, limit)
+	if err != nil {
+		log.Printf("TracerouteAPI: %v", err)
+		http.Error(w, `{"error":"failed to get traceroutes"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(traces)
+}
+
+// ContentChangesAPI mengembalikan perubahan konten terakhir untuk satu URL
+func (h *Handlers) ContentChangesAPI(w http.ResponseWriter, r *http.Request) {
+	urlID, _ := strconv.Atoi(r.URL.Query().Get("url_id"))
+	if urlID <= 0 {
+		http.Error(w, `{"error":"url_id required"}`, http.StatusBadRequest)
+		return
+	}
+	limit := dashboardContentChanges
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if n, convErr := strconv.Atoi(v); convErr == nil && n > 0 && n <= 100 {
+			limit = n
+		}
+	}
+	changes, err := h.App.Store.GetContentChanges(urlID, limit)
+	if err != nil {
+		log.Printf("ContentChangesAPI: %v", err)
+		http.Error(w, `{"error":"failed to get content changes"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(changes)
+}
+
+// parseProbeOptions mengumpulkan field form berprefix "opt_" menjadi opsi mode
+func parseProbeOptions(r *http.Request) map[string]string {
+	options := map[string]string{}
+	for key, values := range r.PostForm {
+		name, ok := strings.CutPrefix(key, "opt_")
+		if !ok || len(values) == 0 {
+			continue
+		}
+		if v := strings.TrimSpace(values[0]); v != "" {
+			options[name] = v
+		}
+	}
+	return options
+}
+
 // getLatestProbeTime mencari waktu probe terbaru dari semua URL
 func getLatestProbeTime(urls []models.TargetURL) time.Time {
 	var latest time.Time
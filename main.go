@@ -60,9 +60,15 @@ func main() {
 	r.HandleFunc("/add", h.AddURL).Methods("POST")
 	r.HandleFunc("/delete/{id:[0-9]+}", h.DeleteURL).Methods("GET")
 	r.HandleFunc("/settings", h.UpdateSettings).Methods("POST")
+	r.HandleFunc("/certificates", h.AddCertificate).Methods("POST")
+	r.HandleFunc("/certificates/delete/{id:[0-9]+}", h.DeleteCertificate).Methods("GET")
+	r.HandleFunc("/credentials", h.AddCredential).Methods("POST")
+	r.HandleFunc("/credentials/delete/{id:[0-9]+}", h.DeleteCredential).Methods("GET")
 	r.HandleFunc("/api/chart", h.ChartAPI).Methods("GET")
 	r.HandleFunc("/api/scheduler/history", h.SchedulerHistoryAPI).Methods("GET")
 	r.HandleFunc("/api/urls", h.URLsAPI).Methods("GET")
+	r.HandleFunc("/api/traceroutes", h.TracerouteAPI).Methods("GET")
+	r.HandleFunc("/api/content-changes", h.ContentChangesAPI).Methods("GET")
 	r.HandleFunc("/favicon.ico", func(w http.ResponseWriter, r *http.Request) {
 		// Pakai logo.png sebagai favicon sederhana (hindari 404 di browser)
 		w.Header().Set("Content-Type", "image/png")
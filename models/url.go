@@ -4,6 +4,10 @@ import (
 	"database/sql"
 	"fmt"
 	"html/template"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -19,16 +23,273 @@ type TargetURL struct {
 	TotalLatencySum int64
 	ProbeMode       string
 	ThreadCount     int
+	PingCount       int
+	// Request HTTP: HTTPHeaders berisi satu "Key: Value" per baris,
+	// HTTPBodyType adalah "json", "form" atau kosong (raw)
+	HTTPMethod   string
+	HTTPHeaders  string
+	HTTPBody     string
+	HTTPBodyType string
+	// Assertions dijalankan terhadap body response HTTP
+	Assertions []Assertion
+	// TimeoutMs adalah batas waktu satu probe; MaxRedirects 0 = redirect tidak diikuti;
+	// AcceptedStatus adalah daftar status code yang dianggap Up, mis. "200-299,301"
+	TimeoutMs      int
+	MaxRedirects   int
+	AcceptedStatus string
+	// KeepAlive true = koneksi dipakai ulang (latency warm), false = koneksi
+	// baru setiap probe (latency cold)
+	KeepAlive bool
+	// ProxyURL: kosong = ikut proxy global, "direct" = tanpa proxy, atau
+	// http(s)://[user:pass@]host:port / socks5://[user:pass@]host:port
+	ProxyURL string
+	// IPFamily: "" = dual-stack, "4" = IPv4 saja, "6" = IPv6 saja;
+	// SourceAddr adalah IP lokal atau nama interface sumber (kosong = default OS)
+	IPFamily   string
+	SourceAddr string
+	// DNSServer adalah resolver untuk hostname target (kosong = sistem);
+	// ResolveIP dihubungi sebagai ganti hasil resolve hostname target, Host
+	// header dan SNI tetap memakai hostname asli
+	DNSServer string
+	ResolveIP string
+	// FanOut: setiap alamat hasil resolve hostname target diprobe sendiri-sendiri;
+	// Degraded diisi scheduler jika pada run terakhir hanya sebagian alamat gagal
+	FanOut   bool
+	Degraded bool
+	// ClientCertID dan CABundleID menunjuk ke tabel certificates (0 = tidak
+	// dipakai); TLSSkipVerify mematikan verifikasi sertifikat server
+	ClientCertID  int
+	CABundleID    int
+	TLSSkipVerify bool
+	// ClientCert dan CABundle diisi scheduler dari tabel certificates sebelum probe
+	ClientCert *Certificate `json:"-"`
+	CABundle   *Certificate `json:"-"`
+	// CredentialID menunjuk ke tabel credentials (0 = tanpa autentikasi);
+	// Credential (beserta secret) diisi scheduler sebelum probe
+	CredentialID int
+	Credential   *Credential `json:"-"`
+	// Steps adalah langkah-langkah HTTP untuk mode journey
+	Steps []JourneyStep
+	// Options berisi pengaturan khusus per mode (mis. record_type, expect)
+	Options map[string]string
+	// TLS berisi sertifikat terakhir untuk mode tls (nil jika belum ada)
+	TLS *TLSInfo
 }
 
-type ProbeHistory struct {
+// Assertion adalah satu pengecekan body response HTTP. Type salah satu dari
+// contains, not_contains, regex, json (Path == Value) atau json_exists (Path).
+type Assertion struct {
+	Type  string
+	Path  string `json:",omitempty"`
+	Value string `json:",omitempty"`
+}
+
+// JourneyStep adalah satu request HTTP di mode journey. URL, Headers dan Body
+// boleh berisi {{variabel}} hasil Extract langkah sebelumnya.
+type JourneyStep struct {
+	Name         string
+	Method       string `json:",omitempty"`
+	URL          string
+	Headers      string            `json:",omitempty"`
+	Body         string            `json:",omitempty"`
+	BodyType     string            `json:",omitempty"`
+	ExpectStatus string            `json:",omitempty"`
+	Assertions   []Assertion       `json:",omitempty"`
+	Extract      map[string]string `json:",omitempty"`
+}
+
+// StepResult adalah hasil satu langkah journey dalam satu run
+type StepResult struct {
+	Index      int
+	Name       string
+	StatusCode int
+	LatencyMs  int64
+	Passed     bool
+	Error      string `json:",omitempty"`
+}
+
+// AddressResult adalah hasil probe satu alamat IP target (mode fan-out) dalam satu run
+type AddressResult struct {
+	IP         string
+	StatusCode int
+	LatencyMs  int64
+	Passed     bool
+	Error      string `json:",omitempty"`
+}
+
+// ContentSnapshot adalah konten body terakhir target yang dipantau
+// (opsi content_watch)
+type ContentSnapshot struct {
+	Hash      string // SHA-256 (hex) konten setelah bagian yang diabaikan dibuang
+	Content   string // konten (dipotong) untuk diff
+	Truncated bool   // Content hanya memuat awal konten yang di-hash
+	UpdatedAt time.Time
+}
+
+// ContentChange mencatat perubahan konten body target antara dua probe
+type ContentChange struct {
+	ID        int64
 	URLID     int
-	URL       string
-	LatencyMs int64
+	HistoryID int64 // baris history saat perubahan terdeteksi
 	Timestamp time.Time
-	StatusCode int
-	Status    string
+	OldHash   string
+	NewHash   string
+	Added     int // jumlah baris yang ditambah
+	Removed   int // jumlah baris yang dihapus
+	// Diff berisi baris "- " (lama), "+ " (baru) dan "  " (konteks)
+	Diff string
+}
+
+// DiffLine adalah satu baris diff konten untuk UI; Kind berisi add, del,
+// ctx (konteks) atau skip (baris yang dilewati)
+type DiffLine struct {
+	Kind string
+	Text string
+}
+
+// DiffLines memecah Diff per baris beserta jenisnya
+func (c ContentChange) DiffLines() []DiffLine {
+	var lines []DiffLine
+	for _, line := range strings.Split(strings.TrimSuffix(c.Diff, "\n"), "\n") {
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "+ "):
+			lines = append(lines, DiffLine{"add", line})
+		case strings.HasPrefix(line, "- "):
+			lines = append(lines, DiffLine{"del", line})
+		case strings.HasPrefix(line, "  "):
+			lines = append(lines, DiffLine{"ctx", line})
+		default:
+			lines = append(lines, DiffLine{"skip", line})
+		}
+	}
+	return lines
+}
+
+// TraceHop adalah satu hop traceroute; IP kosong berarti hop tidak membalas (*)
+type TraceHop struct {
+	TTL     int
+	IP      string `json:",omitempty"`
+	RTTMs   float64
+	Reached bool // hop ini adalah host tujuan
+}
+
+// Traceroute adalah hasil traceroute ke host target yang diambil otomatis saat
+// target berubah dari Up ke Down
+type Traceroute struct {
+	ID        int64
+	URLID     int
+	HistoryID int64 // baris history insiden (Up -> Down)
+	Timestamp time.Time
+	// Incident adalah deskripsi baris history insiden
+	Incident    string
+	Method      string // udp, tcp atau icmp
+	Destination string // IP tujuan, dengan port untuk udp/tcp
+	Reached     bool
+	Error       string `json:",omitempty"`
+	Hops        []TraceHop
+}
+
+// Jenis sertifikat yang bisa di-upload
+const (
+	CertKindClient = "client" // client cert + private key untuk mTLS
+	CertKindCA     = "ca"     // CA bundle untuk verifikasi server
+)
+
+// Certificate adalah client cert/key atau CA bundle (PEM) yang bisa dipasang
+// ke target. KeyPEM tidak pernah ditampilkan di UI.
+type Certificate struct {
+	ID        int
+	Name      string
+	Kind      string
+	CertPEM   string `json:"-"`
+	KeyPEM    string `json:"-"`
+	Subject   string
+	NotAfter  time.Time
+	CreatedAt time.Time
+}
+
+// Jenis credential untuk autentikasi probe HTTP
+const (
+	CredentialBasic  = "basic"   // Authorization: Basic (Username + Secret)
+	CredentialBearer = "bearer"  // Authorization: Bearer Secret
+	CredentialAPIKey = "api_key" // HeaderName: Secret
+	CredentialOAuth2 = "oauth2"  // client credentials: Username = client_id, Secret = client_secret
+)
+
+// Credential adalah secret untuk autentikasi probe. Secret disimpan
+// terenkripsi di database dan tidak pernah dikirim ke UI atau API.
+type Credential struct {
+	ID         int
+	Name       string
+	Kind       string
+	Username   string
+	HeaderName string
+	// TokenURL dan Scopes hanya untuk CredentialOAuth2
+	TokenURL  string
+	Scopes    string
+	Secret    string `json:"-"`
+	CreatedAt time.Time
+}
+
+// TLSInfo adalah detail sertifikat terakhir dari probe mode tls
+type TLSInfo struct {
+	NotAfter   time.Time
+	Issuer     string
+	SANs       []string
+	ChainValid bool
+	Version    string
+	CheckedAt  time.Time
+}
+
+// DaysLeft menghitung sisa hari sebelum sertifikat expired (negatif jika sudah lewat)
+func (ti *TLSInfo) DaysLeft() int {
+	return int(math.Floor(time.Until(ti.NotAfter).Hours() / 24))
+}
+
+// PingStats adalah ringkasan satu seri ping (ICMP echo / TCP dial) dalam satu run
+type PingStats struct {
+	RTTMinMs      float64
+	RTTAvgMs      float64
+	RTTMaxMs      float64
+	JitterMs      float64
+	PacketLossPct float64
+}
+
+// HTTPTimings adalah rincian fase request HTTP (rata-rata per run, dalam ms)
+type HTTPTimings struct {
+	DNSMs      float64
+	ConnectMs  float64
+	TLSMs      float64
+	TTFBMs     float64
+	TransferMs float64
+}
+
+// WSTimings memisahkan latency handshake dan round-trip pesan WebSocket (ms)
+type WSTimings struct {
+	HandshakeMs float64
+	RoundTripMs float64
+}
+
+type ProbeHistory struct {
+	ID          int64
+	URLID       int
+	URL         string
+	LatencyMs   int64
+	Timestamp   time.Time
+	StatusCode  int
+	Status      string
 	Description string
+	// ErrorClass dan ErrorMessage menjelaskan kegagalan probe (kosong jika sukses)
+	ErrorClass   string
+	ErrorMessage string
+	PingStats
+	HTTPTimings
+	WSTimings
+	// Steps berisi hasil per langkah untuk mode journey
+	Steps []StepResult `json:",omitempty"`
+	// Addresses berisi hasil per alamat IP untuk target fan-out
+	Addresses []AddressResult `json:",omitempty"`
 }
 
 type PageData struct {
@@ -36,6 +297,7 @@ type PageData struct {
 	URLs                 []TargetURL
 	CurrentInterval      string
 	SchedulerThreadCount int
+	ProxyURL             string // proxy global, password disamarkan
 	GlobalAvgLatency     int64
 	GlobalUptimePct      int
 	LastCheckedTime      time.Time
@@ -52,6 +314,8 @@ type PageData struct {
 	ChartRange           string
 	NavigatorPages       []int
 	JSONHistoryData      template.JS
+	Traceroutes          []Traceroute    // traceroute insiden URL terpilih (dashboard)
+	ContentChanges       []ContentChange // perubahan konten URL terpilih (dashboard)
 }
 
 // === FUNGSI HELPER UNTUK TEMPLATE ===
@@ -80,6 +344,71 @@ func (tu *TargetURL) GetUptime() string {
 	return "N/A"
 }
 
+// DefaultTimeout dipakai jika target tidak punya timeout sendiri
+const DefaultTimeout = 5 * time.Second
+
+// Timeout mengembalikan batas waktu probe untuk target ini
+func (tu *TargetURL) Timeout() time.Duration {
+	if tu.TimeoutMs <= 0 {
+		return DefaultTimeout
+	}
+	return time.Duration(tu.TimeoutMs) * time.Millisecond
+}
+
+// IsStatusAccepted mengecek apakah status code termasuk AcceptedStatus target
+// (default hanya 200)
+func (tu *TargetURL) IsStatusAccepted(code int) bool {
+	if code <= 0 {
+		return false
+	}
+	ranges, err := ParseStatusSet(tu.AcceptedStatus)
+	if err != nil || len(ranges) == 0 {
+		return code == 200
+	}
+	for _, r := range ranges {
+		if code >= r[0] && code <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseStatusSet mengurai daftar status code seperti "200-299,301" menjadi
+// pasangan [min, max]
+func ParseStatusSet(spec string) ([][2]int, error) {
+	var ranges [][2]int
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		lo, hi, isRange := strings.Cut(part, "-")
+		from, err := strconv.Atoi(strings.TrimSpace(lo))
+		if err != nil {
+			return nil, fmt.Errorf("invalid status code %q", part)
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.Atoi(strings.TrimSpace(hi)); err != nil {
+				return nil, fmt.Errorf("invalid status code range %q", part)
+			}
+		}
+		if from < 100 || to > 599 || from > to {
+			return nil, fmt.Errorf("status code range %q out of bounds", part)
+		}
+		ranges = append(ranges, [2]int{from, to})
+	}
+	return ranges, nil
+}
+
+// Option mengembalikan nilai opsi mode, atau def jika kosong
+func (tu *TargetURL) Option(key string, def string) string {
+	if v := tu.Options[key]; v != "" {
+		return v
+	}
+	return def
+}
+
 // GetAverageLatency menghitung rata-rata latency (sebagai string)
 func (tu *TargetURL) GetAverageLatency() string {
 	if tu.TotalProbeCount == 0 {
@@ -88,3 +417,49 @@ func (tu *TargetURL) GetAverageLatency() string {
 	avg := tu.TotalLatencySum / tu.TotalProbeCount
 	return fmt.Sprintf("%d ms", avg)
 }
+
+// ProxyLabel mengembalikan proxy target tanpa password (untuk ditampilkan di UI)
+func (t TargetURL) ProxyLabel() string {
+	return RedactURL(t.ProxyURL)
+}
+
+// IsDegraded bernilai true jika target fan-out Up tetapi sebagian alamatnya
+// gagal pada run terakhir
+func (t TargetURL) IsDegraded() bool {
+	return t.IsUp && t.FanOut && t.Degraded
+}
+
+// NetworkLabel meringkas pengaturan jaringan target (keluarga IP, alamat
+// sumber, resolver dan IP override) untuk UI
+// (kosong jika memakai default OS)
+func (t TargetURL) NetworkLabel() string {
+	var parts []string
+	switch t.IPFamily {
+	case "4":
+		parts = append(parts, "IPv4 only")
+	case "6":
+		parts = append(parts, "IPv6 only")
+	}
+	if t.SourceAddr != "" {
+		parts = append(parts, "from "+t.SourceAddr)
+	}
+	if t.DNSServer != "" {
+		parts = append(parts, "dns "+t.DNSServer)
+	}
+	if t.ResolveIP != "" {
+		parts = append(parts, "→ "+t.ResolveIP)
+	}
+	if t.FanOut {
+		parts = append(parts, "fan-out")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// RedactURL mengganti password di URL (mis. proxy) dengan "xxxxx"
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User == nil {
+		return rawURL
+	}
+	return u.Redacted()
+}
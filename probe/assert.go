@@ -0,0 +1,208 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"test/models"
+)
+
+// Tipe assertion body yang didukung
+const (
+	AssertContains    = "contains"
+	AssertNotContains = "not_contains"
+	AssertRegex       = "regex"
+	AssertJSONEquals  = "json"
+	AssertJSONExists  = "json_exists"
+)
+
+// maxAssertBodyBytes membatasi body yang dibaca untuk assertion
+const maxAssertBodyBytes = 1 << 20
+
+// ParseAssertions mengubah teks assertion (satu per baris) menjadi daftar assertion.
+// Format tiap baris:
+//
+//	contains: <teks>
+//	not_contains: <teks>
+//	regex: <pola>
+//	json: <path> == <nilai>
+//	json_exists: <path>
+func ParseAssertions(text string) ([]models.Assertion, error) {
+	var assertions []models.Assertion
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		kind, arg, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"type: value\"", i+1)
+		}
+		kind = strings.ToLower(strings.TrimSpace(kind))
+		arg = strings.TrimSpace(arg)
+
+		a := models.Assertion{Type: kind}
+		switch kind {
+		case AssertContains, AssertNotContains:
+			a.Value = arg
+		case AssertRegex:
+			if _, err := regexp.Compile(arg); err != nil {
+				return nil, fmt.Errorf("line %d: %v", i+1, err)
+			}
+			a.Value = arg
+		case AssertJSONEquals:
+			path, value, ok := strings.Cut(arg, "==")
+			if !ok {
+				return nil, fmt.Errorf("line %d: expected \"json: $.path == value\"", i+1)
+			}
+			a.Path, a.Value = strings.TrimSpace(path), strings.TrimSpace(value)
+		case AssertJSONExists:
+			a.Path = arg
+		default:
+			return nil, fmt.Errorf("line %d: unknown assertion type %q", i+1, kind)
+		}
+		if a.Path != "" {
+			if _, err := parseJSONPath(a.Path); err != nil {
+				return nil, fmt.Errorf("line %d: %v", i+1, err)
+			}
+		}
+		assertions = append(assertions, a)
+	}
+	return assertions, nil
+}
+
+// CheckAssertions menjalankan assertion secara berurutan terhadap body dan
+// mengembalikan deskripsi kegagalan pertama, atau "" jika semua lolos.
+func CheckAssertions(body []byte, assertions []models.Assertion) string {
+	var doc any
+	var docErr error
+	docParsed := false
+
+	for _, a := range assertions {
+		switch a.Type {
+		case AssertContains:
+			if !bytes.Contains(body, []byte(a.Value)) {
+				return fmt.Sprintf("Assertion failed: body does not contain %q", a.Value)
+			}
+		case AssertNotContains:
+			if bytes.Contains(body, []byte(a.Value)) {
+				return fmt.Sprintf("Assertion failed: body contains %q", a.Value)
+			}
+		case AssertRegex:
+			re, err := regexp.Compile(a.Value)
+			if err != nil || !re.Match(body) {
+				return fmt.Sprintf("Assertion failed: body does not match /%s/", a.Value)
+			}
+		case AssertJSONEquals, AssertJSONExists:
+			if !docParsed {
+				dec := json.NewDecoder(bytes.NewReader(body))
+				dec.UseNumber()
+				docErr = dec.Decode(&doc)
+				docParsed = true
+			}
+			if docErr != nil {
+				return "Assertion failed: body is not valid JSON"
+			}
+			value, found := lookupJSONPath(doc, a.Path)
+			if !found {
+				return fmt.Sprintf("Assertion failed: %s not found", a.Path)
+			}
+			if a.Type == AssertJSONEquals && jsonValueString(value) != a.Value {
+				return fmt.Sprintf("Assertion failed: %s != %s", a.Path, a.Value)
+			}
+		}
+	}
+	return ""
+}
+
+// parseJSONPath memecah path sederhana seperti $.data.items[0].name atau
+// $['key with space'] menjadi daftar segmen (string untuk key, int untuk index)
+func parseJSONPath(path string) ([]any, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("json path %q must start with $", path)
+	}
+	var segments []any
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("json path %q has an empty key", path)
+			}
+			segments = append(segments, rest[:end])
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("json path %q has an unclosed [", path)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				segments = append(segments, inner[1:len(inner)-1])
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("json path %q has an invalid index [%s]", path, inner)
+			}
+			segments = append(segments, index)
+		default:
+			return nil, fmt.Errorf("json path %q is invalid near %q", path, rest)
+		}
+	}
+	return segments, nil
+}
+
+// lookupJSONPath mengambil nilai pada path dari dokumen JSON yang sudah di-decode
+func lookupJSONPath(doc any, path string) (any, bool) {
+	segments, err := parseJSONPath(path)
+	if err != nil {
+		return nil, false
+	}
+	current := doc
+	for _, seg := range segments {
+		switch key := seg.(type) {
+		case string:
+			obj, ok := current.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			if current, ok = obj[key]; !ok {
+				return nil, false
+			}
+		case int:
+			arr, ok := current.([]any)
+			if !ok || key >= len(arr) {
+				return nil, false
+			}
+			current = arr[key]
+		}
+	}
+	return current, true
+}
+
+// jsonValueString merepresentasikan nilai JSON sebagai teks untuk dibandingkan
+func jsonValueString(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	case bool:
+		return strconv.FormatBool(val)
+	default:
+		b, _ := json.Marshal(val)
+		return string(b)
+	}
+}
@@ -0,0 +1,282 @@
+package probe
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"test/models"
+	"unicode/utf8"
+)
+
+// Mode pemantauan konten body HTTP (opsi content_watch)
+const (
+	ContentWatchOff        = ""
+	ContentWatchHash       = "hash"       // hash body apa adanya
+	ContentWatchNormalized = "normalized" // normalisasi dulu, lalu hash
+)
+
+const (
+	// maxContentSnapshotBytes membatasi konten yang disimpan untuk diff
+	maxContentSnapshotBytes = 256 << 10
+	// maxDiffCells membatasi tabel LCS; perubahan yang lebih besar ditampilkan
+	// sebagai hapus-semua + tambah-semua
+	maxDiffCells = 1 << 20
+	// maxDiffContext adalah jumlah baris tak berubah di sekitar perubahan
+	maxDiffContext = 2
+	// maxDiffLines membatasi panjang diff yang disimpan
+	maxDiffLines = 400
+)
+
+// contentFields dipakai mode http untuk deteksi perubahan konten / defacement
+var contentFields = []Field{
+	{Name: "opt_content_watch", Type: "select", Width: "220px", Row: 3,
+		Choices: []Choice{{ContentWatchOff, "Konten tidak dipantau"}, {ContentWatchHash, "Hash body"}, {ContentWatchNormalized, "Normalisasi + hash"}},
+		Title:   "Catat setiap perubahan body response (mis. diganti halaman maintenance atau defacement yang tetap 200). Hanya 1 MiB pertama body yang dipantau"},
+	{Name: "opt_content_ignore", Type: "textarea", Rows: 2, Row: 3,
+		Placeholder: "Regex yang diabaikan saat hash, satu per baris (opsional)\n\\d{2}:\\d{2}:\\d{2}\nname=\"_token\" value=\"[^\"]*\"",
+		Title:       "Bagian body yang cocok dibuang sebelum di-hash. Mode normalisasi juga membuang token CSRF, nonce, timestamp ISO-8601 dan spasi berlebih"},
+}
+
+// defaultContentIgnore selalu dibuang pada mode normalisasi: nilai token
+// CSRF, nilai atribut nonce dan timestamp ISO-8601. Grup "keep" dipertahankan
+// supaya diff tetap mudah dibaca.
+var defaultContentIgnore = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)(?P<keep>(csrf|xsrf|authenticity_token|_token|__requestverificationtoken)[^>]*?(value|content)\s*=\s*)("[^"]*"|'[^']*')`),
+	regexp.MustCompile(`(?i)(?P<keep>\bnonce\s*=\s*)("[^"]*"|'[^']*')`),
+	regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}(:\d{2}(\.\d+)?)?(Z|[+-]\d{2}:?\d{2})?`),
+}
+
+// configureContentWatch memvalidasi opsi pemantauan konten target
+func configureContentWatch(target *models.TargetURL) error {
+	switch target.Option("content_watch", ContentWatchOff) {
+	case ContentWatchOff, ContentWatchHash, ContentWatchNormalized:
+	default:
+		return fmt.Errorf("invalid content watch mode %q", target.Option("content_watch", ""))
+	}
+	_, err := contentIgnorePatterns(*target)
+	return err
+}
+
+// contentIgnorePatterns mengompilasi regex content_ignore target (satu per baris)
+func contentIgnorePatterns(target models.TargetURL) ([]*regexp.Regexp, error) {
+	var patterns []*regexp.Regexp
+	for _, line := range strings.Split(target.Option("content_ignore", ""), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		re, err := regexp.Compile(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid content ignore regex %q: %w", line, err)
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, nil
+}
+
+// watchesContent melaporkan apakah body target perlu di-hash setiap probe
+func watchesContent(target models.TargetURL) bool {
+	return target.Option("content_watch", ContentWatchOff) != ContentWatchOff
+}
+
+// SnapshotContent membuang bagian body yang diabaikan (dan menormalisasi
+// spasi pada mode normalisasi), lalu menghitung SHA-256-nya. body adalah
+// bagian yang dibaca probe (maks. maxAssertBodyBytes), jadi hash hanya
+// mencakup bagian itu. Konten yang disimpan untuk diff dipotong
+// maxContentSnapshotBytes dan ditandai Truncated; hash tetap atas seluruh
+// konten yang dibaca.
+func SnapshotContent(target models.TargetURL, body []byte) (*models.ContentSnapshot, error) {
+	ignore, err := contentIgnorePatterns(target)
+	if err != nil {
+		return nil, err
+	}
+	content := string(body)
+	normalize := target.Option("content_watch", ContentWatchOff) == ContentWatchNormalized
+	if normalize {
+		for _, re := range defaultContentIgnore {
+			content = re.ReplaceAllString(content, "${keep}")
+		}
+	}
+	for _, re := range ignore {
+		content = re.ReplaceAllString(content, "")
+	}
+	if normalize {
+		content = normalizeWhitespace(content)
+	}
+
+	sum := sha256.Sum256([]byte(content))
+	snap := &models.ContentSnapshot{Hash: hex.EncodeToString(sum[:]), Content: content}
+	if len(content) > maxContentSnapshotBytes {
+		cut := maxContentSnapshotBytes
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		snap.Content, snap.Truncated = content[:cut], true
+	}
+	return snap, nil
+}
+
+// contentBeyondSnapshot menggantikan diff kosong saat hash berubah tetapi
+// perubahannya berada di luar konten yang disimpan
+const contentBeyondSnapshot = "(change beyond stored snapshot)\n"
+
+// DiffSnapshots membandingkan dua snapshot yang hash-nya berbeda. Jika konten
+// yang disimpan sama (perubahan ada setelah batas snapshot), diff berisi
+// catatan, bukan kosong.
+func DiffSnapshots(oldSnap, newSnap models.ContentSnapshot) (diff string, added, removed int) {
+	diff, added, removed = DiffContent(oldSnap.Content, newSnap.Content)
+	if added == 0 && removed == 0 {
+		diff = contentBeyondSnapshot
+	}
+	return diff, added, removed
+}
+
+// normalizeWhitespace merapikan spasi setiap baris dan membuang baris kosong
+func normalizeWhitespace(content string) string {
+	lines := strings.Split(content, "\n")
+	out := lines[:0]
+	for _, line := range lines {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			out = append(out, line)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// diffOp adalah satu baris diff: ' ' sama, '-' dihapus, '+' ditambah
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// DiffContent membandingkan dua konten per baris dan mengembalikan diff
+// ringkas ("- " dihapus, "+ " ditambah, "  " konteks, "…" baris yang
+// dilewati) beserta jumlah baris yang ditambah dan dihapus
+func DiffContent(oldContent, newContent string) (diff string, added, removed int) {
+	ops := diffLines(splitLines(oldContent), splitLines(newContent))
+
+	// Baris sama hanya ditampilkan jika dekat dengan perubahan
+	show := make([]bool, len(ops))
+	for i, op := range ops {
+		if op.kind == ' ' {
+			continue
+		}
+		if op.kind == '+' {
+			added++
+		} else {
+			removed++
+		}
+		for j := max(0, i-maxDiffContext); j <= min(len(ops)-1, i+maxDiffContext); j++ {
+			show[j] = true
+		}
+	}
+
+	var b strings.Builder
+	written, skipped := 0, false
+	for i, op := range ops {
+		if !show[i] {
+			skipped = true
+			continue
+		}
+		if written == maxDiffLines {
+			fmt.Fprintf(&b, "… (diff dipotong, %d baris tidak ditampilkan)\n", len(ops)-i)
+			break
+		}
+		if skipped && written > 0 {
+			b.WriteString("…\n")
+		}
+		skipped = false
+		b.WriteByte(op.kind)
+		b.WriteByte(' ')
+		b.WriteString(op.line)
+		b.WriteByte('\n')
+		written++
+	}
+	return b.String(), added, removed
+}
+
+// splitLines memecah konten per baris (konten kosong = tanpa baris)
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// diffLines menghitung diff baris dengan LCS setelah membuang awalan dan
+// akhiran yang sama
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []diffOp
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if (len(ma)+1)*(len(mb)+1) > maxDiffCells {
+		for _, line := range ma {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range mb {
+			ops = append(ops, diffOp{'+', line})
+		}
+	} else {
+		ops = append(ops, lcsDiff(ma, mb)...)
+	}
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// lcsDiff adalah diff baris klasik berbasis longest common subsequence
+func lcsDiff(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	// lcs[i][j] = panjang LCS a[i:] dan b[j:]
+	lcs := make([][]int32, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, n+m)
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
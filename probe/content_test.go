@@ -0,0 +1,124 @@
+package probe
+
+import (
+	"strings"
+	"test/models"
+	"testing"
+)
+
+func contentTarget(mode, ignore string) models.TargetURL {
+	return models.TargetURL{Options: map[string]string{"content_watch": mode, "content_ignore": ignore}}
+}
+
+func TestSnapshotContentIgnore(t *testing.T) {
+	target := contentTarget(ContentWatchHash, `\d{2}:\d{2}:\d{2}`)
+	a, err := SnapshotContent(target, []byte("<p>Jam 10:00:01</p>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := SnapshotContent(target, []byte("<p>Jam 23:59:59</p>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Hash != b.Hash {
+		t.Errorf("hash differs although only ignored text changed: %q vs %q", a.Content, b.Content)
+	}
+	if a.Content != "<p>Jam </p>" {
+		t.Errorf("content = %q, want ignored text stripped", a.Content)
+	}
+
+	if _, err := SnapshotContent(contentTarget(ContentWatchHash, "("), nil); err == nil {
+		t.Error("invalid ignore regex accepted")
+	}
+}
+
+func TestSnapshotContentNormalized(t *testing.T) {
+	tests := []struct {
+		name, a, b string
+		same       bool
+	}{
+		{"whitespace", "<p>  Halo\tdunia </p>\n\n\n<p>x</p>", "<p> Halo dunia </p>\n<p>x</p>", true},
+		{"csrf token", `<input name="csrf_token" value="abc">`, `<input name="csrf_token" value="xyz">`, true},
+		{"nonce", `<script nonce="r4nd0m">`, `<script nonce='other'>`, true},
+		{"iso timestamp", "build 2024-01-02T03:04:05Z", "build 2025-11-12 13:14:15.123+07:00", true},
+		{"text change", "<h1>Selamat datang</h1>", "<h1>Hacked</h1>", false},
+	}
+	target := contentTarget(ContentWatchNormalized, "")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, _ := SnapshotContent(target, []byte(tt.a))
+			b, _ := SnapshotContent(target, []byte(tt.b))
+			if (a.Hash == b.Hash) != tt.same {
+				t.Errorf("same hash = %v, want %v (%q vs %q)", a.Hash == b.Hash, tt.same, a.Content, b.Content)
+			}
+		})
+	}
+
+	// Nama atribut token tetap ada supaya diff mudah dibaca
+	snap, _ := SnapshotContent(target, []byte(`<input name="_token" value="abc">`))
+	if !strings.Contains(snap.Content, `name="_token" value=`) || strings.Contains(snap.Content, "abc") {
+		t.Errorf("normalized content = %q", snap.Content)
+	}
+}
+
+func TestSnapshotContentTruncated(t *testing.T) {
+	target := contentTarget(ContentWatchHash, "")
+	body := strings.Repeat("a\n", maxContentSnapshotBytes)
+	a, _ := SnapshotContent(target, []byte(body+"lama\n"))
+	b, _ := SnapshotContent(target, []byte(body+"baru\n"))
+	if !a.Truncated || len(a.Content) > maxContentSnapshotBytes {
+		t.Fatalf("truncated = %v, stored %d bytes", a.Truncated, len(a.Content))
+	}
+	if a.Hash == b.Hash {
+		t.Fatal("change after the stored snapshot not detected")
+	}
+	diff, added, removed := DiffSnapshots(*a, *b)
+	if diff != contentBeyondSnapshot || added != 0 || removed != 0 {
+		t.Errorf("diff = %q (+%d/-%d), want note", diff, added, removed)
+	}
+
+	small, _ := SnapshotContent(target, []byte("ok"))
+	if small.Truncated {
+		t.Error("small body marked truncated")
+	}
+}
+
+func TestDiffContent(t *testing.T) {
+	tests := []struct {
+		name, old, new, diff string
+		added, removed       int
+	}{
+		{"identical", "a\nb\n", "a\nb\n", "", 0, 0},
+		{"replace", "a\nb\nc\n", "a\nx\nc\n", "  a\n- b\n+ x\n  c\n", 1, 1},
+		{"from empty", "", "a\nb", "+ a\n+ b\n", 2, 0},
+		{"to empty", "a", "", "- a\n", 0, 1},
+		{"context skip", "1\n2\n3\n4\n5\n6\n7\n8\n", "1\n2\n3\n4\n5\n6\n7\nX\n", "  6\n  7\n- 8\n+ X\n", 1, 1},
+		{"two hunks", "1\n2\n3\n4\n5\n6\n7\n8\n9\n", "X\n2\n3\n4\n5\n6\n7\n8\nY\n",
+			"- 1\n+ X\n  2\n  3\n…\n  7\n  8\n- 9\n+ Y\n", 2, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, added, removed := DiffContent(tt.old, tt.new)
+			if diff != tt.diff || added != tt.added || removed != tt.removed {
+				t.Errorf("DiffContent = %q (+%d/-%d), want %q (+%d/-%d)", diff, added, removed, tt.diff, tt.added, tt.removed)
+			}
+		})
+	}
+}
+
+func TestDiffContentLimit(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < maxDiffLines+50; i++ {
+		b.WriteString("baris\n")
+	}
+	diff, added, _ := DiffContent("", b.String())
+	if added != maxDiffLines+50 {
+		t.Errorf("added = %d, want %d", added, maxDiffLines+50)
+	}
+	if lines := strings.Count(diff, "\n"); lines != maxDiffLines+1 {
+		t.Errorf("diff has %d lines, want %d", lines, maxDiffLines+1)
+	}
+	if !strings.Contains(diff, "50 baris tidak ditampilkan") {
+		t.Errorf("diff does not mention the skipped lines: %q", diff[len(diff)-80:])
+	}
+}
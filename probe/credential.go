@@ -0,0 +1,126 @@
+package probe
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"test/models"
+)
+
+// credentialField dipakai mode HTTP (http, journey, ws). Pilihan credential
+// diisi handler dari tabel credentials; secret tidak pernah ikut ke form.
+var credentialField = Field{Name: "credential_id", Type: "select", ChoicesFrom: "credential", Width: "240px", Row: 9,
+	Title: "Credential untuk autentikasi request"}
+
+// configureCredential membaca credential target dari form
+func configureCredential(target *models.TargetURL, form url.Values) error {
+	target.CredentialID = 0
+	if v := form.Get("credential_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id < 0 {
+			return fmt.Errorf("invalid credential id %q", v)
+		}
+		target.CredentialID = id
+	}
+	return nil
+}
+
+// ValidateCredential memeriksa credential sebelum disimpan
+func ValidateCredential(c models.Credential) error {
+	if c.Secret == "" {
+		return errors.New("credential secret is required")
+	}
+	switch c.Kind {
+	case models.CredentialBasic:
+		if c.Username == "" {
+			return errors.New("basic credential needs a username")
+		}
+	case models.CredentialBearer:
+	case models.CredentialAPIKey:
+		if c.HeaderName == "" || strings.ContainsAny(c.HeaderName, " :\t\r\n") {
+			return fmt.Errorf("invalid API key header name %q", c.HeaderName)
+		}
+	case models.CredentialOAuth2:
+		if c.Username == "" {
+			return errors.New("oauth2 credential needs a client id")
+		}
+		u, err := url.Parse(c.TokenURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid token URL %q", c.TokenURL)
+		}
+	default:
+		return fmt.Errorf("unknown credential kind %q", c.Kind)
+	}
+	return nil
+}
+
+// applyCredential memasang credential target ke request. Untuk OAuth2 access
+// token diambil dari cache atau diminta ke token endpoint (error *TokenError).
+func applyCredential(req *http.Request, target models.TargetURL) error {
+	if target.CredentialID == 0 {
+		return nil
+	}
+	c := target.Credential
+	if c == nil {
+		return fmt.Errorf("credential #%d is not available", target.CredentialID)
+	}
+	switch c.Kind {
+	case models.CredentialBasic:
+		req.SetBasicAuth(c.Username, c.Secret)
+	case models.CredentialBearer:
+		req.Header.Set("Authorization", "Bearer "+c.Secret)
+	case models.CredentialAPIKey:
+		req.Header.Set(c.HeaderName, c.Secret)
+	case models.CredentialOAuth2:
+		token, err := oauth2AccessToken(req.Context(), target)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	default:
+		return fmt.Errorf("unknown credential kind %q", c.Kind)
+	}
+	return nil
+}
+
+// credentialRedirectPolicy membuang header API key saat redirect ke host lain.
+// Header Authorization sudah dibuang net/http sendiri untuk redirect lintas domain.
+func credentialRedirectPolicy(target models.TargetURL, next func(*http.Request, []*http.Request) error) func(*http.Request, []*http.Request) error {
+	c := target.Credential
+	if c == nil || c.Kind != models.CredentialAPIKey {
+		return next
+	}
+	header := credentialHeader(c)
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > 0 && req.URL.Host != via[0].URL.Host {
+			req.Header.Del(header)
+		}
+		return next(req, via)
+	}
+}
+
+// credentialHeader adalah header yang diisi credential
+func credentialHeader(c *models.Credential) string {
+	if c.Kind == models.CredentialAPIKey {
+		return textproto.CanonicalMIMEHeaderKey(c.HeaderName)
+	}
+	return "Authorization"
+}
+
+// credentialFailure dipakai saat credential target tidak bisa dipakai.
+// Kegagalan token endpoint dilaporkan terpisah dari kegagalan target.
+func credentialFailure(err error) ProbeResult {
+	var tokenErr *TokenError
+	if errors.As(err, &tokenErr) {
+		desc := ErrorClassToken.Label()
+		if tokenErr.StatusCode != 0 {
+			desc = fmt.Sprintf("%s (HTTP %d)", desc, tokenErr.StatusCode)
+		}
+		return ProbeResult{NetworkErr: true, Err: err, ErrorClass: ErrorClassToken, Description: desc}
+	}
+	return ProbeResult{NetworkErr: true, Err: err, ErrorClass: ErrorClassOther, Description: "Credential Unavailable"}
+}
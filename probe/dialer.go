@@ -0,0 +1,297 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"test/models"
+)
+
+// Pilihan keluarga alamat IP target (models.TargetURL.IPFamily)
+const (
+	IPFamilyDual = ""  // dual-stack, urutan alamat mengikuti resolver OS
+	IPFamily4    = "4" // hanya IPv4
+	IPFamily6    = "6" // hanya IPv6
+)
+
+// networkFields dipakai semua mode yang membuka koneksi sendiri. Pada mode dns
+// keluarga IP, alamat sumber dan DNS server berlaku untuk query ke resolver.
+var networkFields = []Field{
+	{Name: "ip_family", Type: "select", Width: "200px", Row: 7,
+		Choices: []Choice{{IPFamilyDual, "Dual-stack"}, {IPFamily4, "IPv4 saja"}, {IPFamily6, "IPv6 saja"}},
+		Title:   "Keluarga alamat IP yang dipakai untuk koneksi ke target"},
+	{Name: "source_addr", Type: "text", Row: 7, Placeholder: "Source IP atau interface (kosong = default OS)",
+		Title: "Alamat sumber koneksi: IP lokal (mis. 10.0.0.5) atau nama interface (mis. eth1)"},
+	{Name: "dns_server", Type: "text", Row: 7, Placeholder: "DNS server (kosong = sistem), contoh: 10.0.0.53",
+		Title: "Resolver untuk hostname target, host atau host:port"},
+	{Name: "resolve_ip", Type: "text", Row: 7, Placeholder: "IP override (opsional), contoh: 203.0.113.10",
+		Title: "Hubungi IP ini untuk hostname target; Host header dan SNI tetap hostname asli (seperti curl --resolve)"},
+	{Name: "fan_out", Type: "select", Width: "200px", Row: 7,
+		Choices: []Choice{{"", "Satu alamat"}, {"1", "Semua IP (fan-out)"}},
+		Title:   "Fan-out: probe setiap alamat A/AAAA hostname target; Degraded jika hanya sebagian gagal"},
+}
+
+// withNetwork menambahkan networkFields ke field sebuah mode
+func withNetwork(fields []Field) []Field {
+	return append(fields, networkFields...)
+}
+
+// configureNetwork membaca dan memvalidasi keluarga IP dan alamat sumber target
+func configureNetwork(target *models.TargetURL, form url.Values) error {
+	family := form.Get("ip_family")
+	switch family {
+	case IPFamilyDual, IPFamily4, IPFamily6:
+	default:
+		return fmt.Errorf("invalid IP family %q", family)
+	}
+	source := strings.TrimSpace(form.Get("source_addr"))
+	if ip := net.ParseIP(source); ip != nil {
+		if family != IPFamilyDual && ipFamily(ip) != family {
+			return fmt.Errorf("source address %s is not IPv%s", source, family)
+		}
+	} else if source != "" {
+		if _, err := net.InterfaceByName(source); err != nil {
+			return fmt.Errorf("source %q is neither an IP address nor a network interface", source)
+		}
+	}
+
+	dnsServer := strings.TrimSpace(form.Get("dns_server"))
+	if dnsServer != "" {
+		host := dnsServer
+		if h, _, err := net.SplitHostPort(dnsServer); err == nil {
+			host = h
+		}
+		if host == "" || strings.ContainsAny(host, "/ ") {
+			return fmt.Errorf("invalid DNS server %q", dnsServer)
+		}
+	}
+	resolveIP := strings.TrimSpace(form.Get("resolve_ip"))
+	if resolveIP != "" {
+		ip := net.ParseIP(resolveIP)
+		if ip == nil {
+			return fmt.Errorf("invalid override IP %q", resolveIP)
+		}
+		if family != IPFamilyDual && ipFamily(ip) != family {
+			return fmt.Errorf("override IP %s is not IPv%s", resolveIP, family)
+		}
+		resolveIP = ip.String()
+	}
+
+	fanOut := form.Get("fan_out") == "1"
+	if fanOut && resolveIP != "" {
+		return errors.New("fan-out cannot be combined with an override IP")
+	}
+	// Lewat proxy, koneksi ke setiap IP sebenarnya dibuat proxy
+	proxy := strings.TrimSpace(form.Get("proxy_url"))
+	if fanOut && proxy != "" && proxy != ProxyDirect {
+		return errors.New("fan-out cannot be combined with a proxy")
+	}
+	if u, err := ParseProxyURL(proxy); err == nil {
+		if err := checkProxyResolve(u, family, dnsServer, resolveIP); err != nil {
+			return err
+		}
+	}
+
+	target.IPFamily = family
+	target.SourceAddr = source
+	target.DNSServer = dnsServer
+	target.ResolveIP = resolveIP
+	target.FanOut = fanOut
+	return nil
+}
+
+// ipFamily mengembalikan IPFamily4 atau IPFamily6 untuk ip
+func ipFamily(ip net.IP) string {
+	if ip.To4() != nil {
+		return IPFamily4
+	}
+	return IPFamily6
+}
+
+// familyNetwork menyempitkan network ("tcp", "udp", "ip") ke keluarga alamat
+// target, mis. "tcp" menjadi "tcp6"
+func familyNetwork(network, family string) string {
+	if family == IPFamilyDual || strings.HasSuffix(network, "4") || strings.HasSuffix(network, "6") {
+		return network
+	}
+	return network + family
+}
+
+// targetDialer membuka koneksi dengan keluarga IP, alamat sumber dan resolver
+// target. Koneksi ke hostname target (host) memakai resolveIP jika diisi;
+// host lain (mis. proxy) di-resolve seperti biasa. Memenuhi
+// proxy.ContextDialer sehingga juga dipakai untuk koneksi ke proxy.
+type targetDialer struct {
+	family    string
+	source    string
+	dnsServer string
+	host      string
+	resolveIP string
+	base      net.Dialer
+}
+
+// newTargetDialer membuat dialer untuk pengaturan jaringan target
+func newTargetDialer(target models.TargetURL) *targetDialer {
+	d := &targetDialer{
+		family:    target.IPFamily,
+		source:    target.SourceAddr,
+		dnsServer: target.DNSServer,
+		host:      targetHostname(target.URL),
+		resolveIP: target.ResolveIP,
+	}
+	if d.dnsServer != "" {
+		d.base.Resolver = newResolver(d.dnsServer)
+	}
+	return d
+}
+
+// targetHostname mengambil hostname dari URL atau host[:port] mentah
+func targetHostname(rawURL string) string {
+	host, _, _ := net.SplitHostPort(hostPort(rawURL, "0"))
+	return host
+}
+
+// overrideAddr mengganti host di addr dengan resolveIP jika host adalah
+// hostname target
+func (d *targetDialer) overrideAddr(addr string) string {
+	if d.resolveIP == "" {
+		return addr
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || !strings.EqualFold(host, d.host) {
+		return addr
+	}
+	return net.JoinHostPort(d.resolveIP, port)
+}
+
+func (d *targetDialer) Dial(network, addr string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, addr)
+}
+
+func (d *targetDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	addr = d.overrideAddr(addr)
+	network = familyNetwork(network, d.family)
+	dialer := d.base
+	if d.source != "" {
+		family := d.family
+		if family == IPFamilyDual {
+			var err error
+			if family, err = d.dualStackFamily(ctx, addr); err != nil {
+				return nil, err
+			}
+		}
+		ip, err := sourceIP(d.source, family)
+		if err != nil {
+			return nil, err
+		}
+		dialer.LocalAddr = localAddr(network, ip)
+	}
+	return dialer.DialContext(ctx, network, addr)
+}
+
+// dualStackFamily memilih keluarga alamat sumber untuk target dual-stack.
+// Source berupa IP menentukan keluarganya sendiri; untuk interface dipakai
+// keluarga alamat pertama hasil resolve host tujuan.
+func (d *targetDialer) dualStackFamily(ctx context.Context, addr string) (string, error) {
+	if ip := net.ParseIP(d.source); ip != nil {
+		return ipFamily(ip), nil
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", err
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ipFamily(ip), nil
+	}
+	ips, err := d.base.Resolver.LookupIP(ctx, "ip", host)
+	if err != nil {
+		return "", err
+	}
+	return ipFamily(ips[0]), nil
+}
+
+// sourceIP mengembalikan alamat sumber: source itu sendiri jika berupa IP,
+// atau alamat interface source sesuai keluarga (kosong = alamat pertama)
+func sourceIP(source, family string) (net.IP, error) {
+	if ip := net.ParseIP(source); ip != nil {
+		return ip, nil
+	}
+	iface, err := net.InterfaceByName(source)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range addrs {
+		ipNet, ok := a.(*net.IPNet)
+		if !ok || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		if family == IPFamilyDual || ipFamily(ipNet.IP) == family {
+			return ipNet.IP, nil
+		}
+	}
+	if family == IPFamilyDual {
+		return nil, fmt.Errorf("interface %s has no usable address", source)
+	}
+	return nil, fmt.Errorf("interface %s has no IPv%s address", source, family)
+}
+
+// localAddr membuat net.Addr lokal yang cocok dengan jenis network
+func localAddr(network string, ip net.IP) net.Addr {
+	switch {
+	case strings.HasPrefix(network, "tcp"):
+		return &net.TCPAddr{IP: ip}
+	case strings.HasPrefix(network, "udp"):
+		return &net.UDPAddr{IP: ip}
+	}
+	return &net.IPAddr{IP: ip}
+}
+
+// errNoAddressInFamily dikembalikan jika host tidak punya alamat di keluarga target
+var errNoAddressInFamily = errors.New("host has no address in the selected IP family")
+
+// lookupIP me-resolve host ke satu IP sesuai override, resolver dan keluarga
+// alamat target (untuk mode yang tidak memakai dial, mis. icmp)
+func (d *targetDialer) lookupIP(ctx context.Context, host string) (net.IP, error) {
+	ips, err := d.lookupIPs(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	return ips[0], nil
+}
+
+// lookupIPs seperti lookupIP tetapi mengembalikan semua alamat
+func (d *targetDialer) lookupIPs(ctx context.Context, host string) ([]net.IP, error) {
+	if d.resolveIP != "" && strings.EqualFold(host, d.host) {
+		host = d.resolveIP
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if d.family != IPFamilyDual && ipFamily(ip) != d.family {
+			return nil, errNoAddressInFamily
+		}
+		return []net.IP{ip}, nil
+	}
+	return d.base.Resolver.LookupIP(ctx, familyNetwork("ip", d.family), host)
+}
+
+// ResolveAddresses mengembalikan semua alamat IP hostname target (sesuai
+// resolver dan keluarga alamat target) untuk probe fan-out
+func ResolveAddresses(target models.TargetURL) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), target.Timeout())
+	defer cancel()
+	ips, err := newTargetDialer(target).lookupIPs(ctx, targetHostname(target.URL))
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, ip.String())
+	}
+	return addrs, nil
+}
@@ -0,0 +1,247 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"test/models"
+	"time"
+)
+
+// DNSRecordTypes adalah tipe record yang didukung mode dns
+var DNSRecordTypes = []string{"A", "AAAA", "CNAME", "MX", "TXT", "NS"}
+
+func init() {
+	Register(dnsProber{})
+}
+
+type dnsProber struct{}
+
+func (dnsProber) Name() string                              { return "dns" }
+func (dnsProber) Label() string                             { return "DNS" }
+func (dnsProber) Probe(target models.TargetURL) ProbeResult { return DoDNSProbe(target) }
+
+func (dnsProber) Fields() []Field {
+	return withNetwork([]Field{
+		{Name: "opt_record_type", Type: "select", Choices: Choices(DNSRecordTypes...), Width: "140px"},
+		{Name: "opt_expect", Type: "text", Placeholder: "Expected values (pisahkan dengan koma)"},
+	})
+}
+
+func (dnsProber) Configure(target *models.TargetURL, form url.Values) error {
+	recordType := target.Option("record_type", "A")
+	supported := false
+	for _, t := range DNSRecordTypes {
+		if strings.EqualFold(t, recordType) {
+			supported = true
+		}
+	}
+	if !supported {
+		return fmt.Errorf("unsupported DNS record type %q", recordType)
+	}
+	if err := configureNetwork(target, form); err != nil {
+		return err
+	}
+	// Mode dns tidak menghubungi hostname target, jadi override IP dan
+	// fan-out tidak berarti apa-apa
+	if target.ResolveIP != "" || target.FanOut {
+		return errors.New("override IP and fan-out are not available in dns mode")
+	}
+	return nil
+}
+
+// DoDNSProbe melakukan satu lookup DNS untuk host target dan mengukur waktunya.
+// Resolver tujuan adalah DNSServer target (host atau host:port, kosong =
+// resolver sistem), dihubungi dengan keluarga IP dan alamat sumber target.
+// Opsi target yang dipakai:
+//   - record_type: A/AAAA/CNAME/MX/TXT/NS (default A)
+//   - expect:      daftar nilai (dipisah koma) yang wajib ada di jawaban
+func DoDNSProbe(target models.TargetURL) ProbeResult {
+	host := dnsQueryName(target.URL)
+	recordType := strings.ToUpper(target.Option("record_type", "A"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), target.Timeout())
+	defer cancel()
+
+	startTime := time.Now()
+	answers, err := lookupRecords(ctx, dnsProbeResolver(target), host, recordType)
+	duration := time.Since(startTime)
+
+	if err != nil {
+		return ProbeResult{
+			LatencyMs:   duration.Milliseconds(),
+			RTT:         duration,
+			NetworkErr:  true,
+			Err:         err,
+			Description: describeDNSError(err),
+		}
+	}
+
+	result := ProbeResult{
+		StatusCode: 200,
+		LatencyMs:  duration.Milliseconds(),
+		RTT:        duration,
+	}
+	if missing := missingDNSValues(answers, target.Option("expect", "")); len(missing) > 0 {
+		// Resolver menjawab, tapi isinya tidak sesuai harapan
+		result.StatusCode = 0
+		result.ErrorClass = ErrorClassAssertion
+		result.Err = fmt.Errorf("%s %s answer %v does not contain %v", host, recordType, answers, missing)
+		result.Description = "DNS Answer Mismatch: missing " + strings.Join(missing, ", ")
+	}
+	return result
+}
+
+// dnsQueryName mengambil hostname dari URL atau host mentah
+func dnsQueryName(rawURL string) string {
+	if parsedURL, err := url.Parse(rawURL); err == nil && parsedURL.Host != "" {
+		return parsedURL.Hostname()
+	}
+	return strings.TrimSuffix(rawURL, "/")
+}
+
+// newResolver mengembalikan resolver yang selalu bertanya ke server (host atau
+// host:port). Jika server kosong, resolver sistem yang dipakai.
+func newResolver(server string) *net.Resolver {
+	if server == "" {
+		return net.DefaultResolver
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// dnsProbeResolver membuat resolver mode dns. Query ke resolver (DNSServer
+// atau resolver sistem) dikirim dengan keluarga IP dan alamat sumber target.
+func dnsProbeResolver(target models.TargetURL) *net.Resolver {
+	if target.IPFamily == IPFamilyDual && target.SourceAddr == "" {
+		return newResolver(target.DNSServer)
+	}
+	// Tanpa DNSServer, supaya hostname resolver sendiri di-resolve resolver sistem
+	dialer := newTargetDialer(models.TargetURL{IPFamily: target.IPFamily, SourceAddr: target.SourceAddr})
+	server := target.DNSServer
+	if server != "" {
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			if server != "" {
+				address = server
+			}
+			return dialer.DialContext(ctx, network, address)
+		},
+	}
+}
+
+// lookupRecords menjalankan query sesuai tipe record dan mengembalikan
+// jawaban yang sudah dinormalisasi (huruf kecil, tanpa titik di akhir)
+func lookupRecords(ctx context.Context, r *net.Resolver, host string, recordType string) ([]string, error) {
+	var answers []string
+	switch recordType {
+	case "A", "AAAA":
+		network := "ip4"
+		if recordType == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := r.LookupIP(ctx, network, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			answers = append(answers, ip.String())
+		}
+	case "CNAME":
+		cname, err := r.LookupCNAME(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, cname)
+	case "MX":
+		mxs, err := r.LookupMX(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, mx := range mxs {
+			answers = append(answers, mx.Host)
+		}
+	case "TXT":
+		txts, err := r.LookupTXT(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		// TXT dibiarkan apa adanya (case-sensitive)
+		return txts, nil
+	case "NS":
+		nss, err := r.LookupNS(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range nss {
+			answers = append(answers, ns.Host)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported DNS record type %q", recordType)
+	}
+
+	for i := range answers {
+		answers[i] = normalizeDNSName(answers[i])
+	}
+	return answers, nil
+}
+
+// missingDNSValues mengembalikan nilai expect (dipisah koma) yang tidak ada di answers
+func missingDNSValues(answers []string, expect string) []string {
+	var missing []string
+	for _, want := range strings.Split(expect, ",") {
+		want = strings.TrimSpace(want)
+		if want == "" {
+			continue
+		}
+		found := false
+		for _, got := range answers {
+			if got == want || got == normalizeDNSName(want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, want)
+		}
+	}
+	return missing
+}
+
+func normalizeDNSName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(name), ".")
+}
+
+// describeDNSError membuat deskripsi singkat dari error lookup
+func describeDNSError(err error) string {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		switch {
+		case dnsErr.IsNotFound:
+			return "DNS NXDOMAIN"
+		case dnsErr.IsTimeout:
+			return "DNS Timeout"
+		}
+		return "DNS Error"
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "DNS Timeout"
+	}
+	return "DNS Error"
+}
@@ -0,0 +1,181 @@
+package probe
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"unicode/utf8"
+)
+
+// ErrorClass adalah kategori kegagalan probe, disimpan di probe_history
+// supaya penyebab Down bisa dibedakan tanpa membaca log
+type ErrorClass string
+
+const (
+	ErrorClassNone           ErrorClass = ""
+	ErrorClassDNS            ErrorClass = "dns"
+	ErrorClassRefused        ErrorClass = "connection_refused"
+	ErrorClassConnectTimeout ErrorClass = "connect_timeout"
+	ErrorClassTLS            ErrorClass = "tls"
+	ErrorClassReadTimeout    ErrorClass = "read_timeout"
+	ErrorClassTimeout        ErrorClass = "timeout"
+	ErrorClassReset          ErrorClass = "connection_reset"
+	ErrorClassUnreachable    ErrorClass = "network_unreachable"
+	ErrorClassPermission     ErrorClass = "permission_denied"
+	ErrorClassAssertion      ErrorClass = "assertion"
+	ErrorClassUnhealthy      ErrorClass = "unhealthy"
+	ErrorClassHandshake      ErrorClass = "handshake_failed"
+	ErrorClassToken          ErrorClass = "token_endpoint"
+	ErrorClassOther          ErrorClass = "other"
+)
+
+// Label mengembalikan deskripsi singkat kelas error untuk UI/history
+func (c ErrorClass) Label() string {
+	switch c {
+	case ErrorClassNone:
+		return ""
+	case ErrorClassDNS:
+		return "DNS Resolution Failed"
+	case ErrorClassRefused:
+		return "Connection Refused"
+	case ErrorClassConnectTimeout:
+		return "Connection Timeout"
+	case ErrorClassTLS:
+		return "TLS Error"
+	case ErrorClassReadTimeout:
+		return "Read Timeout"
+	case ErrorClassTimeout:
+		return "Timeout"
+	case ErrorClassReset:
+		return "Connection Reset"
+	case ErrorClassUnreachable:
+		return "Network Unreachable"
+	case ErrorClassPermission:
+		return "Permission Denied"
+	case ErrorClassAssertion:
+		return "Assertion Failed"
+	case ErrorClassUnhealthy:
+		return "Service Unhealthy"
+	case ErrorClassHandshake:
+		return "Handshake Failed"
+	case ErrorClassToken:
+		return "Token Endpoint Failed"
+	}
+	return "Network Error"
+}
+
+// ClassifyError menentukan kelas error dari rantai error (net.OpError,
+// syscall.Errno, error x509/tls, dsb). Timeout yang fasenya tidak diketahui
+// dikembalikan sebagai ErrorClassTimeout.
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ErrorClassNone
+	}
+
+	// Kegagalan token endpoint OAuth2 dibedakan dari kegagalan target,
+	// apa pun penyebab di bawahnya
+	var tokenErr *TokenError
+	if errors.As(err, &tokenErr) {
+		return ErrorClassToken
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return ErrorClassDNS
+	}
+	if errors.Is(err, ErrICMPNotPermitted) || errors.Is(err, os.ErrPermission) {
+		return ErrorClassPermission
+	}
+	if isTLSError(err) {
+		return ErrorClassTLS
+	}
+
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrorClassRefused
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE):
+		return ErrorClassReset
+	case errors.Is(err, syscall.ENETUNREACH), errors.Is(err, syscall.EHOSTUNREACH):
+		return ErrorClassUnreachable
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Timeout() {
+		if opErr.Op == "dial" {
+			return ErrorClassConnectTimeout
+		}
+		return ErrorClassReadTimeout
+	}
+	var netErr net.Error
+	if (errors.As(err, &netErr) && netErr.Timeout()) || errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassTimeout
+	}
+	return classifyMessage(err.Error())
+}
+
+// classifyMessage adalah fallback untuk error yang hanya tersedia sebagai
+// teks (mis. status gRPC Unavailable yang membungkus error dial)
+func classifyMessage(msg string) ErrorClass {
+	switch {
+	case strings.Contains(msg, "no such host"), strings.Contains(msg, "server misbehaving"),
+		strings.Contains(msg, "no suitable address"):
+		return ErrorClassDNS
+	case strings.Contains(msg, "connection refused"):
+		return ErrorClassRefused
+	case strings.Contains(msg, "connection reset"), strings.Contains(msg, "broken pipe"):
+		return ErrorClassReset
+	case strings.Contains(msg, "network is unreachable"), strings.Contains(msg, "no route to host"):
+		return ErrorClassUnreachable
+	case strings.Contains(msg, "tls: "), strings.Contains(msg, "x509: "):
+		return ErrorClassTLS
+	case strings.Contains(msg, "i/o timeout"), strings.Contains(msg, "deadline exceeded"):
+		return ErrorClassTimeout
+	}
+	return ErrorClassOther
+}
+
+func isTLSError(err error) bool {
+	var (
+		verifyErr     *tls.CertificateVerificationError
+		recordErr     tls.RecordHeaderError
+		alertErr      tls.AlertError
+		unknownAuth   x509.UnknownAuthorityError
+		hostnameErr   x509.HostnameError
+		invalidCert   x509.CertificateInvalidError
+		sysRootsErr   x509.SystemRootsError
+		constraintErr x509.ConstraintViolationError
+	)
+	if errors.As(err, &verifyErr) || errors.As(err, &recordErr) || errors.As(err, &alertErr) ||
+		errors.As(err, &unknownAuth) || errors.As(err, &hostnameErr) || errors.As(err, &invalidCert) ||
+		errors.As(err, &sysRootsErr) || errors.As(err, &constraintErr) {
+		return true
+	}
+	// Error handshake yang hanya berupa string dari crypto/tls ditangani
+	// classifyMessage, setelah errno dan timeout dicek
+	return false
+}
+
+// maxErrorMessageLen membatasi pesan error mentah yang disimpan ke history
+const maxErrorMessageLen = 500
+
+// ErrorMessage mengembalikan pesan error mentah (dipotong) untuk disimpan
+func ErrorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	msg := err.Error()
+	if len(msg) > maxErrorMessageLen {
+		// Dipotong di awal rune supaya tidak menyimpan UTF-8 yang rusak
+		cut := maxErrorMessageLen
+		for cut > 0 && !utf8.RuneStart(msg[cut]) {
+			cut--
+		}
+		msg = msg[:cut] + "..."
+	}
+	return msg
+}
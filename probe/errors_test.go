@@ -0,0 +1,52 @@
+package probe
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestClassifyError(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+	tests := []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{
+		{"nil", nil, ErrorClassNone},
+		{"refused", refused, ErrorClassRefused},
+		// Errno di dalam rantai menang atas teks "tls: " pembungkusnya
+		{"refused behind tls text", fmt.Errorf("tls: handshake aborted: %w", refused), ErrorClassRefused},
+		{"typed tls", fmt.Errorf("handshake: %w", tls.AlertError(42)), ErrorClassTLS},
+		{"tls text only", errors.New("remote error: tls: handshake failure"), ErrorClassTLS},
+		{"grpc text refused", errors.New("rpc error: code = Unavailable desc = dial tcp: connection refused"), ErrorClassRefused},
+		{"unknown", errors.New("boom"), ErrorClassOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err); got != tt.want {
+				t.Errorf("ClassifyError(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorMessageTruncatesOnRune(t *testing.T) {
+	// "é" dua byte, sehingga batas maxErrorMessageLen jatuh di tengah rune
+	msg := ErrorMessage(errors.New("x" + strings.Repeat("é", maxErrorMessageLen)))
+	if !utf8.ValidString(msg) {
+		t.Fatalf("truncated message is not valid UTF-8: %q", msg[len(msg)-8:])
+	}
+	if !strings.HasSuffix(msg, "...") || len(msg) > maxErrorMessageLen+len("...") {
+		t.Errorf("unexpected truncation: %d bytes", len(msg))
+	}
+	if got := ErrorMessage(errors.New("pendek")); got != "pendek" {
+		t.Errorf("short message changed: %q", got)
+	}
+}
@@ -0,0 +1,148 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"test/models"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func init() {
+	Register(grpcProber{})
+}
+
+// grpcProber memanggil grpc.health.v1.Health/Check.
+// Opsi target yang dipakai:
+//   - service: nama service yang dicek (kosong = status server secara umum)
+//   - tls:     "1" untuk TLS, kosong untuk plaintext
+type grpcProber struct{}
+
+func (grpcProber) Name() string  { return "grpc" }
+func (grpcProber) Label() string { return "gRPC" }
+
+func (grpcProber) Fields() []Field {
+	return withNetwork(withClientTLS([]Field{
+		{Name: "opt_service", Type: "text", Placeholder: "Service (kosong = seluruh server), contoh: my.pkg.Service"},
+		{Name: "opt_tls", Type: "select", Choices: []Choice{{"", "Plaintext"}, {"1", "TLS"}}, Width: "160px"},
+	}))
+}
+
+func (grpcProber) Configure(target *models.TargetURL, form url.Values) error {
+	if _, port, err := net.SplitHostPort(grpcAddr(*target)); err != nil || port == "" {
+		return errors.New("grpc target needs host:port")
+	}
+	if err := configureNetwork(target, form); err != nil {
+		return err
+	}
+	return configureClientTLS(target, form)
+}
+
+func (grpcProber) Probe(target models.TargetURL) ProbeResult {
+	return DoGRPCProbe(target)
+}
+
+// grpcAddr mengembalikan host:port target; port default 443 hanya untuk TLS
+func grpcAddr(target models.TargetURL) string {
+	if target.Option("tls", "") == "1" {
+		return hostPort(target.URL, "443")
+	}
+	return hostPort(target.URL, "")
+}
+
+// DoGRPCProbe memanggil Health/Check dan memetakan hasilnya:
+// SERVING = Up, UNKNOWN = Warning, NOT_SERVING/SERVICE_UNKNOWN = Down.
+func DoGRPCProbe(target models.TargetURL) ProbeResult {
+	addr := grpcAddr(target)
+
+	creds := insecure.NewCredentials()
+	if target.Option("tls", "") == "1" {
+		host, _, _ := net.SplitHostPort(addr)
+		tlsConfig, err := targetTLSConfig(target, host)
+		if err != nil {
+			return tlsConfigFailure(err)
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	startTime := time.Now()
+	// passthrough: hostname diteruskan ke dialer target supaya resolver dan
+	// IP override target berlaku (resolver dns bawaan gRPC me-resolve sendiri)
+	dialer := newTargetDialer(target)
+	conn, err := grpc.NewClient("passthrough:///"+addr, grpc.WithTransportCredentials(creds),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "tcp", addr)
+		}))
+	if err != nil {
+		return ProbeResult{NetworkErr: true, Err: err, Description: "Invalid Target"}
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), target.Timeout())
+	defer cancel()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{
+		Service: target.Option("service", ""),
+	})
+	duration := time.Since(startTime)
+
+	if err != nil {
+		result := ProbeResult{
+			LatencyMs:  duration.Milliseconds(),
+			RTT:        duration,
+			NetworkErr: true,
+			Err:        err,
+			ErrorClass: grpcErrorClass(err),
+		}
+		switch status.Code(err) {
+		case codes.NotFound:
+			result.NetworkErr = false
+			result.Description = "gRPC SERVICE_UNKNOWN"
+		case codes.Unimplemented:
+			result.NetworkErr = false
+			result.Description = "gRPC Health Service Not Implemented"
+		}
+		return result
+	}
+
+	result := ProbeResult{
+		StatusCode: 200,
+		LatencyMs:  duration.Milliseconds(),
+		RTT:        duration,
+	}
+	switch resp.GetStatus() {
+	case healthpb.HealthCheckResponse_SERVING:
+	case healthpb.HealthCheckResponse_UNKNOWN:
+		result.Status = "Warning"
+		result.Description = "gRPC UNKNOWN"
+	default:
+		result.StatusCode = 0
+		result.ErrorClass = ErrorClassUnhealthy
+		result.Err = fmt.Errorf("health status %s", resp.GetStatus())
+		result.Description = "gRPC " + resp.GetStatus().String()
+	}
+	return result
+}
+
+// grpcErrorClass memetakan status code gRPC ke ErrorClass. Error transport
+// hanya tersedia sebagai teks di status Unavailable.
+func grpcErrorClass(err error) ErrorClass {
+	switch status.Code(err) {
+	case codes.DeadlineExceeded:
+		return ErrorClassTimeout
+	case codes.NotFound, codes.Unimplemented:
+		return ErrorClassUnhealthy
+	case codes.Unavailable:
+		return classifyMessage(status.Convert(err).Message())
+	}
+	return ErrorClassOther
+}
@@ -0,0 +1,376 @@
+package probe
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"test/models"
+	"time"
+)
+
+// HTTPProber menjalankan probe HTTP memakai http.Transport bersama supaya
+// koneksi bisa dipakai ulang antar probe dan antar thread.
+//
+// Transport dibuat per kombinasi pengaturan koneksi target (transportKey):
+// warm (keep-alive, koneksi idle disimpan di pool) atau cold (keep-alive
+// dimatikan, setiap probe membuka koneksi TCP+TLS baru), dan proxy yang dipakai.
+type HTTPProber struct {
+	mu         sync.Mutex
+	transports map[transportKey]*http.Transport
+}
+
+// transportKey adalah pengaturan target yang menentukan transport
+type transportKey struct {
+	keepAlive    bool
+	proxy        proxyKey
+	ipFamily     string
+	sourceAddr   string
+	dnsServer    string
+	resolveHost  string
+	resolveIP    string
+	clientCertID int
+	caBundleID   int
+	skipVerify   bool
+}
+
+// NewHTTPProber membuat prober dengan transport yang sudah di-tuning untuk
+// banyak target dengan interval pendek.
+func NewHTTPProber() *HTTPProber {
+	return &HTTPProber{transports: map[transportKey]*http.Transport{}}
+}
+
+// proxyKey adalah hash URL proxy, supaya kredensial proxy tidak ikut
+// tersimpan di key transport
+type proxyKey [sha256.Size]byte
+
+func newProxyKey(spec string) proxyKey {
+	return sha256.Sum256([]byte(spec))
+}
+
+func newProbeTransport(key transportKey, proxy string, tlsConfig *tls.Config) *http.Transport {
+	dialer := newTargetDialer(models.TargetURL{
+		URL:        key.resolveHost,
+		IPFamily:   key.ipFamily,
+		SourceAddr: key.sourceAddr,
+		DNSServer:  key.dnsServer,
+		ResolveIP:  key.resolveIP,
+	})
+	dialer.base.Timeout = 30 * time.Second
+	dialer.base.KeepAlive = 30 * time.Second
+
+	proxyFunc, dialContext := httpProxyFunc(proxy), dialer.DialContext
+	if u, err := ParseProxyURL(proxy); err == nil && u != nil {
+		if err := checkProxyResolve(u, key.ipFamily, key.dnsServer, key.resolveIP); err != nil {
+			proxyFunc = func(*http.Request) (*url.URL, error) { return nil, err }
+		} else if u.Scheme == "socks5" {
+			// http.Transport selalu mengirim hostname ke proxy SOCKS, jadi socks5
+			// (resolve lokal) didial sendiri
+			proxyFunc = nil
+			dialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+				return dialSOCKS(ctx, dialer, u, addr)
+			}
+		}
+	}
+	return &http.Transport{
+		Proxy:                 proxyFunc,
+		DisableKeepAlives:     !key.keepAlive,
+		TLSClientConfig:       tlsConfig,
+		DialContext:           dialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          512,
+		MaxIdleConnsPerHost:   16,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// newTransportKey mengambil pengaturan target yang menentukan transport
+func newTransportKey(target models.TargetURL) transportKey {
+	key := transportKey{
+		keepAlive:    target.KeepAlive,
+		proxy:        newProxyKey(target.ProxyURL),
+		ipFamily:     target.IPFamily,
+		sourceAddr:   target.SourceAddr,
+		dnsServer:    target.DNSServer,
+		resolveIP:    target.ResolveIP,
+		clientCertID: target.ClientCertID,
+		caBundleID:   target.CABundleID,
+		skipVerify:   target.TLSSkipVerify,
+	}
+	if target.ResolveIP != "" {
+		// Override hanya berlaku untuk hostname target, jadi ikut menentukan transport
+		key.resolveHost = targetHostname(target.URL)
+	}
+	return key
+}
+
+// transport mengembalikan transport untuk pengaturan target (dibuat saat
+// pertama kali dipakai). Sertifikat dikenali dari ID-nya; sertifikat yang
+// di-upload ulang selalu mendapat ID baru.
+func (p *HTTPProber) transport(target models.TargetURL) (*http.Transport, error) {
+	key := newTransportKey(target)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if t, ok := p.transports[key]; ok {
+		return t, nil
+	}
+	tlsConfig, err := targetTLSConfig(target, "")
+	if err != nil {
+		return nil, err
+	}
+	t := newProbeTransport(key, target.ProxyURL, tlsConfig)
+	p.transports[key] = t
+	return t, nil
+}
+
+// PruneFanOutTransports membuang transport per alamat milik target fan-out
+// untuk IP yang tidak lagi ada di ips (mis. IP CDN atau round-robin yang
+// sudah berganti), supaya transport tidak menumpuk untuk setiap IP yang
+// pernah di-resolve
+func PruneFanOutTransports(target models.TargetURL, ips []string) {
+	defaultHTTPProber.pruneAddresses(target, ips)
+}
+
+// PruneProxyTransports membuang transport yang proxy-nya tidak ada di proxies
+// (proxy efektif semua target, "" untuk proxy environment), mis. setelah
+// proxy global diganti atau target dihapus
+func PruneProxyTransports(proxies []string) {
+	defaultHTTPProber.pruneProxies(proxies)
+}
+
+func (p *HTTPProber) pruneProxies(proxies []string) {
+	keep := map[proxyKey]bool{}
+	for _, proxy := range proxies {
+		keep[newProxyKey(proxy)] = true
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, t := range p.transports {
+		if keep[key.proxy] {
+			continue
+		}
+		t.CloseIdleConnections()
+		delete(p.transports, key)
+	}
+}
+
+func (p *HTTPProber) pruneAddresses(target models.TargetURL, ips []string) {
+	target.ResolveIP = ""
+	base := newTransportKey(target)
+	host := targetHostname(target.URL)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, t := range p.transports {
+		if key.resolveIP == "" || key.resolveHost != host || slices.Contains(ips, key.resolveIP) {
+			continue
+		}
+		rest := key
+		rest.resolveIP, rest.resolveHost = "", ""
+		if rest != base {
+			continue
+		}
+		t.CloseIdleConnections()
+		delete(p.transports, key)
+	}
+}
+
+// CloseIdleConnections menutup koneksi idle di semua transport
+func (p *HTTPProber) CloseIdleConnections() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, t := range p.transports {
+		t.CloseIdleConnections()
+	}
+}
+
+// Probe menjalankan satu kali HTTP request (method, header dan body sesuai
+// pengaturan target) dan mengukur waktu.
+func (p *HTTPProber) Probe(target models.TargetURL) ProbeResult {
+	req, err := newHTTPRequest(target)
+	if err != nil {
+		return ProbeResult{NetworkErr: true, Err: err, Description: "Invalid Request"}
+	}
+	if err := applyCredential(req, target); err != nil {
+		return credentialFailure(err)
+	}
+
+	transport, err := p.transport(target)
+	if err != nil {
+		return tlsConfigFailure(err)
+	}
+
+	timer := &httpTimer{}
+	req = timer.withTrace(req)
+
+	// Client murah untuk dibuat; yang mahal (pool koneksi) ada di transport
+	client := http.Client{
+		Transport:     transport,
+		Timeout:       target.Timeout(),
+		CheckRedirect: credentialRedirectPolicy(target, redirectPolicy(target.MaxRedirects)),
+	}
+
+	startTime := time.Now()
+
+	resp, err := client.Do(req)
+
+	duration := time.Since(startTime)
+	milliseconds := duration.Milliseconds()
+
+	if err != nil {
+		return ProbeResult{
+			StatusCode: 0,
+			LatencyMs:  milliseconds,
+			RTT:        duration,
+			NetworkErr: true,
+			Err:        err,
+			ErrorClass: timer.classify(err),
+			Timings:    timer.timings(),
+		}
+	}
+	// Body yang sudah dibaca habis sebelum Close membuat koneksi bisa kembali
+	// ke pool. Jika body lebih besar dari maxTransferBytes, koneksi ditutup.
+	defer resp.Body.Close()
+	forgetRejectedToken(target, resp.StatusCode)
+
+	result := ProbeResult{
+		StatusCode: resp.StatusCode,
+		LatencyMs:  milliseconds,
+		RTT:        duration,
+		NetworkErr: false,
+	}
+
+	// Body dibaca sampai habis (maks. maxTransferBytes) untuk mengukur fase
+	// transfer; bagian awalnya disimpan jika ada assertion atau konten dipantau.
+	var body []byte
+	var readErr error
+	if len(target.Assertions) > 0 || watchesContent(target) {
+		body, readErr = io.ReadAll(io.LimitReader(resp.Body, maxAssertBodyBytes))
+	}
+	if readErr == nil {
+		_, readErr = io.Copy(io.Discard, io.LimitReader(resp.Body, maxTransferBytes))
+	}
+	timer.mark(&timer.bodyDone)
+	result.Timings = timer.timings()
+
+	if len(target.Assertions) > 0 {
+		if readErr != nil {
+			result.Err = readErr
+			result.ErrorClass = ClassifyError(readErr)
+			result.Status = "Down"
+			result.Description = "Assertion failed: could not read body"
+		} else if failure := CheckAssertions(body, target.Assertions); failure != "" {
+			result.ErrorClass = ErrorClassAssertion
+			result.Status = "Down"
+			result.Description = failure
+		}
+	}
+	if watchesContent(target) && readErr == nil && result.Status != "Down" {
+		// Regex sudah divalidasi saat target disimpan
+		result.Content, _ = SnapshotContent(target, body)
+	}
+	return result
+}
+
+// defaultHTTPProber dipakai bersama oleh mode http dan journey supaya pool
+// koneksinya sama
+var defaultHTTPProber = NewHTTPProber()
+
+func init() {
+	Register(defaultHTTPProber)
+}
+
+func (p *HTTPProber) Name() string  { return "http" }
+func (p *HTTPProber) Label() string { return "HTTP" }
+
+func (p *HTTPProber) Fields() []Field {
+	return withNetwork(withClientTLS([]Field{
+		{Name: "http_method", Type: "select", Choices: Choices(HTTPMethods...), Width: "140px"},
+		{Name: "http_headers", Type: "textarea", Rows: 3, Placeholder: "Header, satu per baris\nAccept: application/json\nX-Api-Key: ..."},
+		{Name: "http_body_type", Type: "select", Choices: []Choice{{"", "Raw"}, {"json", "JSON"}, {"form", "Form"}}, Width: "140px"},
+		{Name: "http_body", Type: "textarea", Rows: 3, Placeholder: "Body (opsional)"},
+		{Name: "http_assertions", Type: "textarea", Rows: 3, Row: 1,
+			Placeholder: "Assertion body, satu per baris (opsional)\ncontains: Welcome\nnot_contains: Maintenance\nregex: version\":\\s*\"\\d+\njson: $.status == ok\njson_exists: $.data.id"},
+		{Name: "max_redirects", Type: "number", Min: "0", Default: "10", Placeholder: "Max redirect",
+			Title: "Jumlah redirect maksimal (0 = tidak mengikuti redirect)", Width: "160px", Row: 2},
+		{Name: "keep_alive", Type: "select", Choices: []Choice{{"1", "Keep-alive"}, {"0", "Koneksi baru"}},
+			Title: "Keep-alive mengukur latency warm, koneksi baru mengukur latency cold (TCP+TLS setiap probe)", Width: "200px", Row: 2},
+		{Name: "accepted_status", Type: "text", Default: "200", Placeholder: "Status Up, contoh: 200-299,301",
+			Title: "Status code yang dianggap Up", Row: 2},
+		contentFields[0],
+		contentFields[1],
+		proxyField,
+		credentialField,
+	}))
+}
+
+func (p *HTTPProber) Configure(target *models.TargetURL, form url.Values) error {
+	if !strings.HasPrefix(target.URL, "http://") && !strings.HasPrefix(target.URL, "https://") {
+		target.URL = "https://" + target.URL
+	}
+
+	target.HTTPMethod = strings.ToUpper(strings.TrimSpace(form.Get("http_method")))
+	if target.HTTPMethod == "" {
+		target.HTTPMethod = http.MethodGet
+	}
+	if !slices.Contains(HTTPMethods, target.HTTPMethod) {
+		return fmt.Errorf("invalid HTTP method %q", target.HTTPMethod)
+	}
+
+	target.HTTPHeaders = strings.TrimSpace(form.Get("http_headers"))
+	target.HTTPBody = form.Get("http_body")
+	target.HTTPBodyType = form.Get("http_body_type")
+	if target.HTTPBodyType != "json" && target.HTTPBodyType != "form" {
+		target.HTTPBodyType = ""
+	}
+	if target.HTTPBodyType == "json" && target.HTTPBody != "" && !json.Valid([]byte(target.HTTPBody)) {
+		return errors.New("body is not valid JSON")
+	}
+
+	assertions, err := ParseAssertions(form.Get("http_assertions"))
+	if err != nil {
+		return err
+	}
+	target.Assertions = assertions
+
+	// Redirect policy (0 = jangan ikuti redirect)
+	target.MaxRedirects = 10
+	if mr, err := strconv.Atoi(form.Get("max_redirects")); err == nil && mr >= 0 {
+		target.MaxRedirects = mr
+	}
+	target.KeepAlive = form.Get("keep_alive") != "0"
+
+	target.AcceptedStatus = strings.TrimSpace(form.Get("accepted_status"))
+	if target.AcceptedStatus == "" {
+		target.AcceptedStatus = "200"
+	}
+	if _, err := models.ParseStatusSet(target.AcceptedStatus); err != nil {
+		return err
+	}
+	if err := configureClientTLS(target, form); err != nil {
+		return err
+	}
+	if err := configureCredential(target, form); err != nil {
+		return err
+	}
+	if err := configureContentWatch(target); err != nil {
+		return err
+	}
+	if err := configureNetwork(target, form); err != nil {
+		return err
+	}
+	return configureProxy(target, form)
+}
@@ -0,0 +1,198 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"test/models"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// Nomor protokol ICMP untuk icmp.ParseMessage
+const (
+	protocolICMP     = 1
+	protocolIPv6ICMP = 58
+)
+
+// ErrICMPNotPermitted dikembalikan jika socket ICMP datagram (unprivileged)
+// maupun raw socket tidak bisa dibuka oleh proses ini.
+var ErrICMPNotPermitted = errors.New("icmp: neither unprivileged datagram nor raw sockets are permitted (check net.ipv4.ping_group_range or run with CAP_NET_RAW)")
+
+func init() {
+	Register(icmpProber{})
+}
+
+// icmpProber mengirim seri PingCount ICMP echo per thread
+type icmpProber struct{}
+
+func (icmpProber) Name() string                              { return "icmp" }
+func (icmpProber) Label() string                             { return "ICMP" }
+func (icmpProber) Fields() []Field                           { return withNetwork([]Field{pingCountField}) }
+func (icmpProber) Probe(target models.TargetURL) ProbeResult { return DoICMPProbe(target) }
+func (icmpProber) Samples(target models.TargetURL) int       { return pingSamples(target) }
+
+func (icmpProber) Configure(target *models.TargetURL, form url.Values) error {
+	if err := configureNetwork(target, form); err != nil {
+		return err
+	}
+	return configurePingCount(target, form)
+}
+
+// icmpSeq dipakai supaya echo yang berjalan paralel punya sequence berbeda
+var icmpSeq uint32
+
+// DoICMPProbe mengirim satu ICMP echo request ke host target dan mengukur RTT
+// sampai echo reply diterima. IPv4 dan IPv6 didukung.
+func DoICMPProbe(target models.TargetURL) ProbeResult {
+	startTime := time.Now()
+
+	ip, err := resolveICMPTarget(target)
+	if err != nil {
+		return ProbeResult{LatencyMs: time.Since(startTime).Milliseconds(), NetworkErr: true, Err: err}
+	}
+	source, err := icmpSource(target, ip)
+	if err != nil {
+		return ProbeResult{LatencyMs: time.Since(startTime).Milliseconds(), NetworkErr: true, Err: err}
+	}
+
+	rtt, err := icmpEcho(ip, source, target.Timeout())
+	if err != nil {
+		return ProbeResult{LatencyMs: time.Since(startTime).Milliseconds(), NetworkErr: true, Err: err}
+	}
+
+	return ProbeResult{
+		StatusCode: 200,
+		LatencyMs:  rtt.Milliseconds(),
+		RTT:        rtt,
+		NetworkErr: false,
+	}
+}
+
+// resolveICMPTarget mengambil hostname dari URL (atau host mentah) dan
+// me-resolve ke IP sesuai keluarga alamat target
+func resolveICMPTarget(target models.TargetURL) (net.IP, error) {
+	rawURL := target.URL
+	host := rawURL
+	if parsedURL, err := url.Parse(rawURL); err == nil && parsedURL.Host != "" {
+		host = parsedURL.Hostname()
+	} else if h, _, err := net.SplitHostPort(rawURL); err == nil {
+		host = h
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), target.Timeout())
+	defer cancel()
+	return newTargetDialer(target).lookupIP(ctx, host)
+}
+
+// icmpSource mengembalikan alamat sumber echo (nil = default OS)
+func icmpSource(target models.TargetURL, ip net.IP) (net.IP, error) {
+	if target.SourceAddr == "" {
+		return nil, nil
+	}
+	source, err := sourceIP(target.SourceAddr, ipFamily(ip))
+	if err != nil {
+		return nil, err
+	}
+	if ipFamily(source) != ipFamily(ip) {
+		return nil, fmt.Errorf("source address %s cannot reach %s", source, ip)
+	}
+	return source, nil
+}
+
+// listenICMP membuka socket ICMP untuk keluarga alamat ip, terikat ke
+// source jika diisi. Socket datagram (unprivileged) dicoba lebih dulu, lalu
+// raw socket sebagai fallback. Nilai privileged menunjukkan socket mana yang
+// berhasil dibuka.
+func listenICMP(ip, source net.IP) (conn *icmp.PacketConn, privileged bool, err error) {
+	dgramNet, rawNet, laddr := "udp4", "ip4:icmp", "0.0.0.0"
+	if ip.To4() == nil {
+		dgramNet, rawNet, laddr = "udp6", "ip6:ipv6-icmp", "::"
+	}
+	if source != nil {
+		laddr = source.String()
+	}
+
+	conn, dgramErr := icmp.ListenPacket(dgramNet, laddr)
+	if dgramErr == nil {
+		return conn, false, nil
+	}
+	conn, rawErr := icmp.ListenPacket(rawNet, laddr)
+	if rawErr == nil {
+		return conn, true, nil
+	}
+	if errors.Is(dgramErr, os.ErrPermission) || errors.Is(rawErr, os.ErrPermission) {
+		return nil, false, ErrICMPNotPermitted
+	}
+	return nil, false, fmt.Errorf("icmp: %v; %v", dgramErr, rawErr)
+}
+
+// icmpEcho mengirim satu echo request ke ip (dari source, nil = default OS)
+// dan menunggu reply yang cocok
+func icmpEcho(ip, source net.IP, timeout time.Duration) (time.Duration, error) {
+	conn, privileged, err := listenICMP(ip, source)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	var (
+		reqType   icmp.Type = ipv4.ICMPTypeEcho
+		replyType icmp.Type = ipv4.ICMPTypeEchoReply
+		proto               = protocolICMP
+	)
+	if ip.To4() == nil {
+		reqType, replyType, proto = ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply, protocolIPv6ICMP
+	}
+
+	// Pada socket datagram kernel mengganti ID dengan port lokal, jadi ID
+	// hanya dicocokkan saat memakai raw socket.
+	id := os.Getpid() & 0xffff
+	seq := int(atomic.AddUint32(&icmpSeq, 1) & 0xffff)
+	msg := icmp.Message{
+		Type: reqType,
+		Body: &icmp.Echo{ID: id, Seq: seq, Data: []byte("probeMulti")},
+	}
+	wb, err := msg.Marshal(nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var dst net.Addr = &net.UDPAddr{IP: ip}
+	if privileged {
+		dst = &net.IPAddr{IP: ip}
+	}
+
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return 0, err
+	}
+
+	startTime := time.Now()
+	if _, err := conn.WriteTo(wb, dst); err != nil {
+		return 0, err
+	}
+
+	rb := make([]byte, 1500)
+	for {
+		n, _, err := conn.ReadFrom(rb)
+		if err != nil {
+			return 0, err
+		}
+		reply, err := icmp.ParseMessage(proto, rb[:n])
+		if err != nil || reply.Type != replyType {
+			continue
+		}
+		echo, ok := reply.Body.(*icmp.Echo)
+		if !ok || echo.Seq != seq || (privileged && echo.ID != id) {
+			continue
+		}
+		return time.Since(startTime), nil
+	}
+}
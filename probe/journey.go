@@ -0,0 +1,380 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"test/models"
+	"time"
+)
+
+// defaultStepStatus adalah status yang dianggap lolos jika step tidak menentukan
+const defaultStepStatus = "200-299"
+
+func init() {
+	Register(&journeyProber{http: defaultHTTPProber})
+}
+
+// journeyProber menjalankan beberapa request HTTP berurutan dalam satu sesi
+// (cookie jar bersama), dengan ekstraksi variabel antar langkah dan
+// assertion per langkah. URL target menjadi base untuk URL step yang relatif.
+type journeyProber struct {
+	http *HTTPProber
+}
+
+func (p *journeyProber) Name() string  { return "journey" }
+func (p *journeyProber) Label() string { return "HTTP Journey" }
+
+func (p *journeyProber) Fields() []Field {
+	return withNetwork(withClientTLS([]Field{
+		{Name: "journey_steps", Type: "textarea", Rows: 8, Placeholder: journeyPlaceholder},
+		proxyField,
+		credentialField,
+	}))
+}
+
+const journeyPlaceholder = `Langkah-langkah (JSON), contoh:
+[
+  {"name": "login", "method": "POST", "url": "/api/login", "body_type": "json",
+   "body": "{\"user\": \"monitor\", \"password\": \"...\"}",
+   "assert": ["json_exists: $.token"], "extract": {"token": "$.token"}},
+  {"name": "profile", "url": "/api/me", "headers": {"Authorization": "Bearer {{token}}"},
+   "status": "200", "assert": ["json: $.active == true"]}
+]
+extract: "$.path" (JSON), "header:Nama-Header", atau "regex:pola(grup)"`
+
+func (p *journeyProber) Configure(target *models.TargetURL, form url.Values) error {
+	if !strings.HasPrefix(target.URL, "http://") && !strings.HasPrefix(target.URL, "https://") {
+		target.URL = "https://" + target.URL
+	}
+	steps, err := ParseJourney(form.Get("journey_steps"))
+	if err != nil {
+		return err
+	}
+	if len(steps) == 0 {
+		return errors.New("journey needs at least one step")
+	}
+	target.Steps = steps
+	target.MaxRedirects = 10
+	target.KeepAlive = true
+	if err := configureClientTLS(target, form); err != nil {
+		return err
+	}
+	if err := configureCredential(target, form); err != nil {
+		return err
+	}
+	if err := configureNetwork(target, form); err != nil {
+		return err
+	}
+	return configureProxy(target, form)
+}
+
+// journeyStepInput adalah format JSON yang diketik user di form
+type journeyStepInput struct {
+	Name     string            `json:"name"`
+	Method   string            `json:"method"`
+	URL      string            `json:"url"`
+	Headers  map[string]string `json:"headers"`
+	Body     string            `json:"body"`
+	BodyType string            `json:"body_type"`
+	Status   string            `json:"status"`
+	Assert   []string          `json:"assert"`
+	Extract  map[string]string `json:"extract"`
+}
+
+// ParseJourney mengurai definisi journey (array JSON) menjadi JourneyStep
+func ParseJourney(text string) ([]models.JourneyStep, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, nil
+	}
+	var inputs []journeyStepInput
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&inputs); err != nil {
+		return nil, fmt.Errorf("invalid journey JSON: %w", err)
+	}
+
+	steps := make([]models.JourneyStep, 0, len(inputs))
+	for i, in := range inputs {
+		step := models.JourneyStep{
+			Name:         in.Name,
+			Method:       strings.ToUpper(in.Method),
+			URL:          in.URL,
+			Body:         in.Body,
+			BodyType:     in.BodyType,
+			ExpectStatus: in.Status,
+			Extract:      in.Extract,
+		}
+		if step.Name == "" {
+			step.Name = fmt.Sprintf("step %d", i+1)
+		}
+		if step.Method == "" {
+			step.Method = http.MethodGet
+		}
+		if step.URL == "" {
+			return nil, fmt.Errorf("%s: url is required", step.Name)
+		}
+		if step.ExpectStatus == "" {
+			step.ExpectStatus = defaultStepStatus
+		}
+		if _, err := models.ParseStatusSet(step.ExpectStatus); err != nil {
+			return nil, fmt.Errorf("%s: %w", step.Name, err)
+		}
+
+		// Header disimpan dalam format baris yang sama dengan mode http
+		names := make([]string, 0, len(in.Headers))
+		for name := range in.Headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var lines []string
+		for _, name := range names {
+			lines = append(lines, name+": "+in.Headers[name])
+		}
+		step.Headers = strings.Join(lines, "\n")
+
+		assertions, err := ParseAssertions(strings.Join(in.Assert, "\n"))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", step.Name, err)
+		}
+		step.Assertions = assertions
+
+		for name, spec := range in.Extract {
+			if err := validateExtract(spec); err != nil {
+				return nil, fmt.Errorf("%s: extract %s: %w", step.Name, name, err)
+			}
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+func validateExtract(spec string) error {
+	switch {
+	case strings.HasPrefix(spec, "$"):
+		_, err := parseJSONPath(spec)
+		return err
+	case strings.HasPrefix(spec, "header:"):
+		return nil
+	case strings.HasPrefix(spec, "regex:"):
+		re, err := regexp.Compile(strings.TrimPrefix(spec, "regex:"))
+		if err != nil {
+			return err
+		}
+		if re.NumSubexp() < 1 {
+			return errors.New("regex needs a capture group")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported extract %q (use $.path, header:Name or regex:...)", spec)
+}
+
+// Probe menjalankan semua langkah berurutan; berhenti di langkah pertama yang
+// gagal. Timeout target berlaku untuk seluruh journey, bukan per langkah.
+func (p *journeyProber) Probe(target models.TargetURL) ProbeResult {
+	transport, err := p.http.transport(target)
+	if err != nil {
+		return tlsConfigFailure(err)
+	}
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{
+		Transport:     transport,
+		Jar:           jar,
+		CheckRedirect: credentialRedirectPolicy(target, redirectPolicy(target.MaxRedirects)),
+	}
+	base, err := url.Parse(target.URL)
+	if err != nil {
+		return ProbeResult{NetworkErr: true, Err: err, Description: "Invalid Request"}
+	}
+	if target.CredentialID != 0 && target.Credential == nil {
+		return credentialFailure(fmt.Errorf("credential #%d is not available", target.CredentialID))
+	}
+
+	// Setiap langkah sudah dinilai dengan ExpectStatus-nya sendiri, jadi
+	// journey yang lolos selalu melapor 200 (AcceptedStatus target default)
+	ctx, cancel := context.WithTimeout(context.Background(), target.Timeout())
+	defer cancel()
+	vars := map[string]string{}
+	result := ProbeResult{StatusCode: 200}
+	var total time.Duration
+
+	for i, step := range target.Steps {
+		sr, elapsed, failure := runJourneyStep(ctx, client, base, target, step, vars)
+		sr.Index = i + 1
+		total += elapsed
+		result.Steps = append(result.Steps, sr)
+
+		if failure != nil {
+			result.StatusCode = 0
+			result.Err = failure.err
+			result.ErrorClass = failure.class
+			result.NetworkErr = failure.class != ErrorClassAssertion
+			result.Description = fmt.Sprintf("Step %d (%s) failed: %s", sr.Index, step.Name, sr.Error)
+			break
+		}
+	}
+
+	result.LatencyMs = total.Milliseconds()
+	result.RTT = total
+	return result
+}
+
+// stepFailure menyimpan penyebab gagalnya satu langkah
+type stepFailure struct {
+	err   error
+	class ErrorClass
+}
+
+// runJourneyStep menjalankan satu langkah dalam ctx journey dan mengisi vars dari Extract
+func runJourneyStep(ctx context.Context, client *http.Client, base *url.URL, target models.TargetURL, step models.JourneyStep, vars map[string]string) (models.StepResult, time.Duration, *stepFailure) {
+	sr := models.StepResult{Name: step.Name}
+	fail := func(err error, class ErrorClass) *stepFailure {
+		sr.Error = err.Error()
+		return &stepFailure{err: err, class: class}
+	}
+
+	rawURL, err := substituteVars(step.URL, vars)
+	if err != nil {
+		return sr, 0, fail(err, ErrorClassOther)
+	}
+	ref, err := url.Parse(rawURL)
+	if err != nil {
+		return sr, 0, fail(err, ErrorClassOther)
+	}
+	headers, err := substituteVars(step.Headers, vars)
+	if err != nil {
+		return sr, 0, fail(err, ErrorClassOther)
+	}
+	body, err := substituteVars(step.Body, vars)
+	if err != nil {
+		return sr, 0, fail(err, ErrorClassOther)
+	}
+
+	req, err := newHTTPRequest(models.TargetURL{
+		URL:          base.ResolveReference(ref).String(),
+		HTTPMethod:   step.Method,
+		HTTPHeaders:  headers,
+		HTTPBody:     body,
+		HTTPBodyType: step.BodyType,
+	})
+	if err != nil {
+		return sr, 0, fail(err, ErrorClassOther)
+	}
+	req = req.WithContext(ctx)
+	// Credential target hanya dipasang ke langkah yang menuju host target dan
+	// tidak mengatur header autentikasinya sendiri
+	if c := target.Credential; c != nil && req.URL.Host == base.Host && req.Header.Get(credentialHeader(c)) == "" {
+		if err := applyCredential(req, target); err != nil {
+			return sr, 0, fail(err, ClassifyError(err))
+		}
+	}
+
+	startTime := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		elapsed := time.Since(startTime)
+		sr.LatencyMs = elapsed.Milliseconds()
+		return sr, elapsed, fail(err, ClassifyError(err))
+	}
+	respBody, readErr := io.ReadAll(io.LimitReader(resp.Body, maxAssertBodyBytes))
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxTransferBytes))
+	resp.Body.Close()
+	elapsed := time.Since(startTime)
+	if req.URL.Host == base.Host {
+		forgetRejectedToken(target, resp.StatusCode)
+	}
+
+	sr.StatusCode = resp.StatusCode
+	sr.LatencyMs = elapsed.Milliseconds()
+	if readErr != nil {
+		return sr, elapsed, fail(readErr, ClassifyError(readErr))
+	}
+
+	expected := models.TargetURL{AcceptedStatus: step.ExpectStatus}
+	if !expected.IsStatusAccepted(resp.StatusCode) {
+		return sr, elapsed, fail(fmt.Errorf("unexpected status %d (want %s)", resp.StatusCode, step.ExpectStatus), ErrorClassAssertion)
+	}
+	if failure := CheckAssertions(respBody, step.Assertions); failure != "" {
+		return sr, elapsed, fail(errors.New(failure), ErrorClassAssertion)
+	}
+
+	names := make([]string, 0, len(step.Extract))
+	for name := range step.Extract {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value, ok := extractValue(step.Extract[name], resp, respBody)
+		if !ok {
+			return sr, elapsed, fail(fmt.Errorf("could not extract %s from %s", name, step.Extract[name]), ErrorClassAssertion)
+		}
+		vars[name] = value
+	}
+
+	sr.Passed = true
+	return sr, elapsed, nil
+}
+
+// journeyVarPattern mencocokkan {{nama}} di URL, header dan body
+var journeyVarPattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// substituteVars mengganti {{nama}} dengan nilai variabel. Variabel yang
+// belum diekstrak dianggap error supaya request tidak terkirim setengah jadi.
+func substituteVars(text string, vars map[string]string) (string, error) {
+	var missing []string
+	out := journeyVarPattern.ReplaceAllStringFunc(text, func(m string) string {
+		name := journeyVarPattern.FindStringSubmatch(m)[1]
+		value, ok := vars[name]
+		if !ok {
+			missing = append(missing, name)
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("undefined variable %s", strings.Join(missing, ", "))
+	}
+	return out, nil
+}
+
+// extractValue mengambil nilai dari response sesuai spec ($.path,
+// header:Nama atau regex:pola dengan satu capture group)
+func extractValue(spec string, resp *http.Response, body []byte) (string, bool) {
+	switch {
+	case strings.HasPrefix(spec, "$"):
+		var doc any
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		if err := dec.Decode(&doc); err != nil {
+			return "", false
+		}
+		value, found := lookupJSONPath(doc, spec)
+		if !found {
+			return "", false
+		}
+		return jsonValueString(value), true
+	case strings.HasPrefix(spec, "header:"):
+		value := resp.Header.Get(strings.TrimSpace(strings.TrimPrefix(spec, "header:")))
+		return value, value != ""
+	case strings.HasPrefix(spec, "regex:"):
+		re, err := regexp.Compile(strings.TrimPrefix(spec, "regex:"))
+		if err != nil {
+			return "", false
+		}
+		m := re.FindSubmatch(body)
+		if len(m) < 2 {
+			return "", false
+		}
+		return string(m[1]), true
+	}
+	return "", false
+}
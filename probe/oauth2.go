@@ -0,0 +1,195 @@
+package probe
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"test/models"
+	"time"
+)
+
+const (
+	// tokenExpiryMargin: token diperbarui sedikit sebelum benar-benar expired
+	tokenExpiryMargin = 30 * time.Second
+	// defaultTokenLifetime dipakai jika token endpoint tidak mengirim expires_in
+	defaultTokenLifetime  = 5 * time.Minute
+	maxTokenResponseBytes = 64 << 10
+)
+
+// TokenError menandai kegagalan di token endpoint OAuth2 (bukan di target)
+type TokenError struct {
+	StatusCode int // 0 jika token endpoint tidak bisa dihubungi
+	Err        error
+}
+
+func (e *TokenError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("token endpoint returned HTTP %d: %v", e.StatusCode, e.Err)
+	}
+	return "token endpoint: " + e.Err.Error()
+}
+
+func (e *TokenError) Unwrap() error { return e.Err }
+
+// oauth2Token adalah access token di cache beserta masa berlakunya
+type oauth2Token struct {
+	mu          sync.Mutex
+	accessToken string
+	expiry      time.Time
+	// paramAuth: token endpoint menolak client auth lewat header Basic, jadi
+	// client_id/client_secret dikirim di body
+	paramAuth bool
+}
+
+// tokenCache menyimpan token per credential. Key memuat isi konfigurasi
+// supaya credential yang berubah tidak memakai token lama.
+var tokenCache = struct {
+	sync.Mutex
+	tokens map[string]*oauth2Token
+}{tokens: map[string]*oauth2Token{}}
+
+func tokenCacheKey(c *models.Credential) string {
+	return fmt.Sprintf("%d|%s|%s|%s", c.ID, c.TokenURL, c.Username, c.Scopes)
+}
+
+func cachedToken(c *models.Credential) *oauth2Token {
+	tokenCache.Lock()
+	defer tokenCache.Unlock()
+	key := tokenCacheKey(c)
+	t, ok := tokenCache.tokens[key]
+	if !ok {
+		t = &oauth2Token{}
+		tokenCache.tokens[key] = t
+	}
+	return t
+}
+
+// invalidateToken membuang token credential dari cache
+func invalidateToken(c *models.Credential) {
+	t := cachedToken(c)
+	t.mu.Lock()
+	t.accessToken = ""
+	t.mu.Unlock()
+}
+
+// forgetRejectedToken membuang token OAuth2 yang ditolak target (HTTP 401)
+// supaya probe berikutnya meminta token baru
+func forgetRejectedToken(target models.TargetURL, statusCode int) {
+	if c := target.Credential; c != nil && c.Kind == models.CredentialOAuth2 && statusCode == http.StatusUnauthorized {
+		invalidateToken(c)
+	}
+}
+
+// oauth2AccessToken mengambil token untuk credential OAuth2 target. Token
+// endpoint dihubungi lewat proxy dan pengaturan jaringan target (tanpa
+// pengaturan TLS target, karena client cert/CA bundle milik target) dan
+// dibatasi timeout target.
+func oauth2AccessToken(ctx context.Context, target models.TargetURL) (string, error) {
+	rt, err := defaultHTTPProber.transport(models.TargetURL{
+		KeepAlive:  true,
+		ProxyURL:   target.ProxyURL,
+		IPFamily:   target.IPFamily,
+		SourceAddr: target.SourceAddr,
+		DNSServer:  target.DNSServer,
+	})
+	if err != nil {
+		return "", &TokenError{Err: err}
+	}
+	ctx, cancel := context.WithTimeout(ctx, target.Timeout())
+	defer cancel()
+	return fetchOAuth2Token(ctx, target.Credential, rt)
+}
+
+// fetchOAuth2Token mengembalikan access token dari cache, atau meminta token
+// baru dengan grant client_credentials jika belum ada / hampir expired.
+func fetchOAuth2Token(ctx context.Context, c *models.Credential, rt http.RoundTripper) (string, error) {
+	t := cachedToken(c)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.accessToken != "" && time.Now().Add(tokenExpiryMargin).Before(t.expiry) {
+		return t.accessToken, nil
+	}
+
+	// Coba client auth lewat header Basic dulu (RFC 6749 2.3.1); sebagian
+	// provider hanya menerima client_id/client_secret di body
+	resp, err := requestOAuth2Token(ctx, c, rt, t.paramAuth)
+	var tokenErr *TokenError
+	if !t.paramAuth && errors.As(err, &tokenErr) && (tokenErr.StatusCode == http.StatusBadRequest || tokenErr.StatusCode == http.StatusUnauthorized) {
+		if resp, err = requestOAuth2Token(ctx, c, rt, true); err == nil {
+			t.paramAuth = true
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	lifetime := defaultTokenLifetime
+	if resp.ExpiresIn > 0 {
+		lifetime = time.Duration(resp.ExpiresIn) * time.Second
+	}
+	t.accessToken = resp.AccessToken
+	t.expiry = time.Now().Add(lifetime)
+	return t.accessToken, nil
+}
+
+type oauth2TokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func requestOAuth2Token(ctx context.Context, c *models.Credential, rt http.RoundTripper, paramAuth bool) (*oauth2TokenResponse, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if c.Scopes != "" {
+		form.Set("scope", strings.Join(strings.Fields(strings.ReplaceAll(c.Scopes, ",", " ")), " "))
+	}
+	if paramAuth {
+		form.Set("client_id", c.Username)
+		form.Set("client_secret", c.Secret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, &TokenError{Err: err}
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if !paramAuth {
+		req.SetBasicAuth(url.QueryEscape(c.Username), url.QueryEscape(c.Secret))
+	}
+
+	resp, err := (&http.Client{Transport: rt}).Do(req)
+	if err != nil {
+		return nil, &TokenError{Err: err}
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTokenResponseBytes))
+	if err != nil {
+		return nil, &TokenError{Err: err}
+	}
+
+	var token oauth2TokenResponse
+	jsonErr := json.Unmarshal(body, &token)
+	if resp.StatusCode != http.StatusOK {
+		reason := errors.New(http.StatusText(resp.StatusCode))
+		if jsonErr == nil && token.Error != "" {
+			reason = errors.New(strings.TrimSpace(token.Error + " " + token.ErrorDescription))
+		}
+		return nil, &TokenError{StatusCode: resp.StatusCode, Err: reason}
+	}
+	if jsonErr != nil {
+		return nil, &TokenError{StatusCode: resp.StatusCode, Err: fmt.Errorf("invalid token response: %w", jsonErr)}
+	}
+	if token.AccessToken == "" {
+		return nil, &TokenError{StatusCode: resp.StatusCode, Err: errors.New("response has no access_token")}
+	}
+	return &token, nil
+}
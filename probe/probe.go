@@ -1,50 +1,140 @@
 package probe
 
 import (
-	"net"
+	"context"
+	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"test/models"
 	"time"
 )
 
 type ProbeResult struct {
 	StatusCode int
 	LatencyMs  int64
+	RTT        time.Duration
 	NetworkErr bool
+	Err        error
+	// ErrorClass mengkategorikan kegagalan (lihat ClassifyError); jika kosong
+	// dan Err terisi, Run mengisinya otomatis
+	ErrorClass ErrorClass
+	// Status dan Description (opsional) menggantikan status/deskripsi umum di probe_history
+	Status      string
+	Description string
+	// TLS diisi oleh probe mode tls
+	TLS *models.TLSInfo
+	// Timings berisi rincian fase request (khusus HTTP)
+	Timings *models.HTTPTimings
+	// WS berisi latency handshake dan round-trip (khusus WebSocket)
+	WS *models.WSTimings
+	// Steps berisi hasil tiap langkah (khusus journey)
+	Steps []models.StepResult
+	// Content berisi hash (dan konten untuk diff) body HTTP jika konten target dipantau
+	Content *models.ContentSnapshot
 }
 
-// DoHTTPProbe menjalankan satu kali HTTP GET probe dan mengukur waktu.
-func DoHTTPProbe(urlStr string) ProbeResult {
-	startTime := time.Now()
+// maxTransferBytes membatasi body yang dibaca untuk mengukur fase transfer
+const maxTransferBytes = 10 << 20
+
+// HTTPMethods adalah method yang boleh dipakai target HTTP
+var HTTPMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
 
-	client := http.Client{
-		Timeout: 5 * time.Second,
+// redirectPolicy membatasi jumlah redirect yang diikuti. Dengan max 0,
+// response redirect dikembalikan apa adanya (status 3xx dinilai lewat
+// AcceptedStatus target).
+func redirectPolicy(max int) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if max <= 0 {
+			return http.ErrUseLastResponse
+		}
+		if len(via) > max {
+			return fmt.Errorf("stopped after %d redirects", max)
+		}
+		return nil
 	}
+}
 
-	resp, err := client.Get(urlStr)
+// newHTTPRequest membangun request dari pengaturan HTTP target
+func newHTTPRequest(target models.TargetURL) (*http.Request, error) {
+	method := target.HTTPMethod
+	if method == "" {
+		method = http.MethodGet
+	}
 
-	duration := time.Since(startTime)
-	milliseconds := duration.Milliseconds()
+	var body io.Reader
+	if target.HTTPBody != "" {
+		body = strings.NewReader(target.HTTPBody)
+	}
 
+	req, err := http.NewRequest(method, target.URL, body)
 	if err != nil {
-		return ProbeResult{
-			StatusCode: 0,
-			LatencyMs:  milliseconds,
-			NetworkErr: true,
+		return nil, err
+	}
+	for key, values := range ParseHeaderLines(target.HTTPHeaders) {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+	// Content-Type mengikuti tipe body, kecuali sudah diset lewat header
+	if target.HTTPBody != "" && req.Header.Get("Content-Type") == "" {
+		switch target.HTTPBodyType {
+		case "json":
+			req.Header.Set("Content-Type", "application/json")
+		case "form":
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 		}
 	}
-	defer resp.Body.Close()
+	// Header Host khusus harus dipindah ke req.Host agar dipakai net/http
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+		req.Header.Del("Host")
+	}
+	return req, nil
+}
 
-	return ProbeResult{
-		StatusCode: resp.StatusCode,
-		LatencyMs:  milliseconds,
-		NetworkErr: false,
+// ParseHeaderLines mengubah teks "Key: Value" (satu per baris) menjadi http.Header.
+// Baris kosong atau tanpa ":" diabaikan.
+func ParseHeaderLines(text string) http.Header {
+	header := http.Header{}
+	for _, line := range strings.Split(text, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			continue
+		}
+		header.Add(key, strings.TrimSpace(value))
 	}
+	return header
+}
+
+// pingCountField dipakai mode yang mengirim seri ping (tcp, icmp)
+var pingCountField = Field{Name: "ping_count", Type: "number", Min: "1", Max: "100", Default: "5",
+	Placeholder: "Pings", Title: "Echo/dial per run", Width: "120px"}
+
+// configurePingCount membaca jumlah echo/dial per run dari form; nilai di
+// atas pingCountField.Max ditolak karena setiap run harus selesai sebelum
+// jadwal berikutnya
+func configurePingCount(target *models.TargetURL, form url.Values) error {
+	target.PingCount = 1
+	if pc, err := strconv.Atoi(form.Get("ping_count")); err == nil && pc > 0 {
+		if limit, _ := strconv.Atoi(pingCountField.Max); pc > limit {
+			return fmt.Errorf("ping count %d exceeds the maximum of %d", pc, limit)
+		}
+		target.PingCount = pc
+	}
+	return nil
+}
+
+func pingSamples(target models.TargetURL) int {
+	return max(target.PingCount, 1)
 }
 
 // DoTCPPing attempts to open a TCP connection to a host:port
-func DoTCPPing(rawURL string) ProbeResult {
+func DoTCPPing(target models.TargetURL) ProbeResult {
+	rawURL := target.URL
 	startTime := time.Now()
 
 	parsedURL, err := url.Parse(rawURL)
@@ -61,7 +151,9 @@ func DoTCPPing(rawURL string) ProbeResult {
 		}
 	}
 
-	conn, err := net.DialTimeout("tcp", targetHost, 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), target.Timeout())
+	defer cancel()
+	conn, err := dialTCP(ctx, target, targetHost)
 	duration := time.Since(startTime)
 	milliseconds := duration.Milliseconds()
 
@@ -69,22 +161,17 @@ func DoTCPPing(rawURL string) ProbeResult {
 		return ProbeResult{
 			StatusCode: 0,
 			LatencyMs:  milliseconds,
+			RTT:        duration,
 			NetworkErr: true,
+			Err:        err,
 		}
 	}
 	conn.Close()
 
 	return ProbeResult{
-		StatusCode: 200, 
+		StatusCode: 200,
 		LatencyMs:  milliseconds,
+		RTT:        duration,
 		NetworkErr: false,
 	}
 }
-
-// DoICMPProbe is an ICMP-like probe.
-// For now, it performs a TCP dial to a common port as a proxy for reachability.
-// This is NOT a real ICMP ping, which is more complex and may require elevated privileges.
-func DoICMPProbe(rawURL string) ProbeResult {
-	// Using DoTCPPing as a stand-in for an ICMP-like check.
-	return DoTCPPing(rawURL)
-}
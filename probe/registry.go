@@ -0,0 +1,135 @@
+package probe
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"test/models"
+)
+
+// DefaultMode dipakai untuk target yang mode-nya kosong atau tidak dikenal
+const DefaultMode = "http"
+
+// Prober adalah satu jenis probe (http, tcp, icmp, ...). Setiap prober
+// mendeklarasikan field konfigurasi miliknya sendiri; scheduler, handler dan
+// form di halaman URL dibangun dari registry sehingga protokol baru cukup
+// ditambahkan di package ini.
+type Prober interface {
+	// Name adalah nama mode yang disimpan di kolom probe_mode
+	Name() string
+	// Label adalah nama yang ditampilkan di UI
+	Label() string
+	// Fields adalah schema form untuk mode ini
+	Fields() []Field
+	// Configure membaca field form milik mode ini ke target lalu memvalidasinya
+	Configure(target *models.TargetURL, form url.Values) error
+	// Probe menjalankan satu kali probe terhadap target
+	Probe(target models.TargetURL) ProbeResult
+}
+
+// SeriesProber adalah prober yang mengirim satu seri sample per thread
+// (mis. ping), bukan satu probe saja
+type SeriesProber interface {
+	Samples(target models.TargetURL) int
+}
+
+// Field mendeskripsikan satu input form milik prober. Name adalah nama input
+// form; field berprefix "opt_" otomatis disimpan ke TargetURL.Options.
+type Field struct {
+	Name        string
+	Type        string // text, number, select atau textarea
+	Placeholder string
+	Title       string
+	Default     string
+	Choices     []Choice // untuk select
+	ChoicesFrom string   // select yang pilihannya diisi handler dari data, mis. sertifikat
+	Min         string   // untuk number
+	Max         string
+	Rows        int    // untuk textarea
+	Width       string // max-width di form, kosong = lebar penuh
+	Row         int    // field dengan Row sama ditampilkan dalam satu baris
+}
+
+// Choice adalah satu pilihan pada field select
+type Choice struct {
+	Value string
+	Label string
+}
+
+// Choices membuat pilihan select yang value dan label-nya sama
+func Choices(values ...string) []Choice {
+	choices := make([]Choice, len(values))
+	for i, v := range values {
+		choices[i] = Choice{Value: v, Label: v}
+	}
+	return choices
+}
+
+var registry = map[string]Prober{}
+
+// Register mendaftarkan prober. Dipanggil dari init() file masing-masing prober.
+func Register(p Prober) {
+	if _, exists := registry[p.Name()]; exists {
+		panic(fmt.Sprintf("probe: prober %q registered twice", p.Name()))
+	}
+	registry[p.Name()] = p
+}
+
+// Get mengembalikan prober untuk mode, ok=false jika tidak terdaftar
+func Get(mode string) (Prober, bool) {
+	p, ok := registry[mode]
+	return p, ok
+}
+
+// Lookup mengembalikan prober untuk mode, atau prober DefaultMode jika mode
+// tidak dikenal
+func Lookup(mode string) Prober {
+	if p, ok := registry[mode]; ok {
+		return p
+	}
+	return registry[DefaultMode]
+}
+
+// Probers mengembalikan semua prober: DefaultMode lebih dulu, sisanya urut nama
+// (urutan init() antar file tidak bisa diandalkan untuk UI)
+func Probers() []Prober {
+	probers := make([]Prober, 0, len(registry))
+	for _, p := range registry {
+		probers = append(probers, p)
+	}
+	sort.Slice(probers, func(i, j int) bool {
+		if (probers[i].Name() == DefaultMode) != (probers[j].Name() == DefaultMode) {
+			return probers[i].Name() == DefaultMode
+		}
+		return probers[i].Name() < probers[j].Name()
+	})
+	return probers
+}
+
+// Run menjalankan prober dan melengkapi ErrorClass jika prober belum mengisinya
+func Run(p Prober, target models.TargetURL) ProbeResult {
+	result := p.Probe(target)
+	if result.Err != nil && result.ErrorClass == ErrorClassNone {
+		result.ErrorClass = ClassifyError(result.Err)
+	}
+	return result
+}
+
+// FormRows mengelompokkan field prober per baris (Field.Row)
+func FormRows(p Prober) [][]Field {
+	byRow := map[int][]Field{}
+	var rows []int
+	for _, f := range p.Fields() {
+		if _, seen := byRow[f.Row]; !seen {
+			rows = append(rows, f.Row)
+		}
+		byRow[f.Row] = append(byRow[f.Row], f)
+	}
+	sort.Ints(rows)
+
+	grouped := make([][]Field, 0, len(rows))
+	for _, row := range rows {
+		grouped = append(grouped, byRow[row])
+	}
+	return grouped
+}
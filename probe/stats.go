@@ -0,0 +1,99 @@
+package probe
+
+import (
+	"math"
+	"test/models"
+	"time"
+)
+
+// ComputePingStats menghitung min/avg/max RTT, jitter (standar deviasi RTT)
+// dan persentase packet loss dari sekumpulan hasil probe. Hasil tanpa status
+// (StatusCode 0) atau dengan Err dihitung sebagai paket yang hilang, termasuk
+// kegagalan assertion dan expect mismatch, dan RTT-nya tidak ikut dihitung.
+func ComputePingStats(results []ProbeResult) models.PingStats {
+	var stats models.PingStats
+	if len(results) == 0 {
+		return stats
+	}
+
+	var rtts []float64
+	for _, r := range results {
+		if r.StatusCode == 0 || r.Err != nil {
+			continue
+		}
+		rtts = append(rtts, float64(r.RTT)/float64(time.Millisecond))
+	}
+
+	stats.PacketLossPct = 100 * float64(len(results)-len(rtts)) / float64(len(results))
+	if len(rtts) == 0 {
+		return stats
+	}
+
+	stats.RTTMinMs, stats.RTTMaxMs = rtts[0], rtts[0]
+	var sum float64
+	for _, v := range rtts {
+		sum += v
+		stats.RTTMinMs = math.Min(stats.RTTMinMs, v)
+		stats.RTTMaxMs = math.Max(stats.RTTMaxMs, v)
+	}
+	stats.RTTAvgMs = sum / float64(len(rtts))
+
+	var variance float64
+	for _, v := range rtts {
+		variance += (v - stats.RTTAvgMs) * (v - stats.RTTAvgMs)
+	}
+	stats.JitterMs = math.Sqrt(variance / float64(len(rtts)))
+
+	return stats
+}
+
+// AverageTimings merata-ratakan rincian fase HTTP dari hasil yang memilikinya
+func AverageTimings(results []ProbeResult) models.HTTPTimings {
+	var avg models.HTTPTimings
+	var n float64
+	for _, r := range results {
+		if r.Timings == nil {
+			continue
+		}
+		avg.DNSMs += r.Timings.DNSMs
+		avg.ConnectMs += r.Timings.ConnectMs
+		avg.TLSMs += r.Timings.TLSMs
+		avg.TTFBMs += r.Timings.TTFBMs
+		avg.TransferMs += r.Timings.TransferMs
+		n++
+	}
+	if n == 0 {
+		return avg
+	}
+	avg.DNSMs /= n
+	avg.ConnectMs /= n
+	avg.TLSMs /= n
+	avg.TTFBMs /= n
+	avg.TransferMs /= n
+	return avg
+}
+
+// AverageWSTimings merata-ratakan latency handshake dan round-trip WebSocket.
+// Round-trip hanya dihitung dari probe yang benar-benar bertukar pesan.
+func AverageWSTimings(results []ProbeResult) models.WSTimings {
+	var avg models.WSTimings
+	var handshakes, roundTrips float64
+	for _, r := range results {
+		if r.WS == nil {
+			continue
+		}
+		avg.HandshakeMs += r.WS.HandshakeMs
+		handshakes++
+		if r.WS.RoundTripMs > 0 {
+			avg.RoundTripMs += r.WS.RoundTripMs
+			roundTrips++
+		}
+	}
+	if handshakes > 0 {
+		avg.HandshakeMs /= handshakes
+	}
+	if roundTrips > 0 {
+		avg.RoundTripMs /= roundTrips
+	}
+	return avg
+}
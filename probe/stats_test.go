@@ -0,0 +1,59 @@
+package probe
+
+import (
+	"errors"
+	"regexp"
+	"test/models"
+	"testing"
+	"time"
+)
+
+func TestComputePingStatsMismatch(t *testing.T) {
+	expect := regexp.MustCompile(`^\+PONG`)
+	ok := ProbeResult{StatusCode: 200, RTT: 10 * time.Millisecond}
+	mismatch := tcpMismatch(time.Now().Add(-time.Millisecond), expect, []byte("-ERR unknown command"))
+
+	stats := ComputePingStats([]ProbeResult{ok, mismatch, ok, mismatch})
+	if stats.PacketLossPct != 50 {
+		t.Errorf("packet loss = %v%%, want 50%%", stats.PacketLossPct)
+	}
+	if stats.RTTMinMs != 10 || stats.RTTMaxMs != 10 || stats.JitterMs != 0 {
+		t.Errorf("mismatch RTT counted: min %v max %v jitter %v", stats.RTTMinMs, stats.RTTMaxMs, stats.JitterMs)
+	}
+
+	if stats := ComputePingStats([]ProbeResult{mismatch, mismatch}); stats.PacketLossPct != 100 {
+		t.Errorf("all mismatches: packet loss = %v%%, want 100%%", stats.PacketLossPct)
+	}
+}
+
+func TestComputePingStats(t *testing.T) {
+	ok := func(ms int) ProbeResult {
+		return ProbeResult{StatusCode: 200, RTT: time.Duration(ms) * time.Millisecond}
+	}
+	lost := ProbeResult{NetworkErr: true, Err: errors.New("timeout")}
+
+	tests := []struct {
+		name    string
+		results []ProbeResult
+		want    models.PingStats
+	}{
+		{"empty", nil, models.PingStats{}},
+		{"all lost", []ProbeResult{lost, lost, lost}, models.PingStats{PacketLossPct: 100}},
+		{"no loss", []ProbeResult{ok(10), ok(10)},
+			models.PingStats{RTTMinMs: 10, RTTAvgMs: 10, RTTMaxMs: 10}},
+		{"partial loss", []ProbeResult{ok(10), lost, ok(30), lost},
+			models.PingStats{RTTMinMs: 10, RTTAvgMs: 20, RTTMaxMs: 30, JitterMs: 10, PacketLossPct: 50}},
+		// Simpangan baku populasi dari 2, 4, 4, 4, 5, 5, 7, 9 adalah 2
+		{"jitter", []ProbeResult{ok(2), ok(4), ok(4), ok(4), ok(5), ok(5), ok(7), ok(9)},
+			models.PingStats{RTTMinMs: 2, RTTAvgMs: 5, RTTMaxMs: 9, JitterMs: 2}},
+		{"no status code counts as lost", []ProbeResult{ok(10), {RTT: time.Millisecond}},
+			models.PingStats{RTTMinMs: 10, RTTAvgMs: 10, RTTMaxMs: 10, PacketLossPct: 50}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ComputePingStats(tt.results); got != tt.want {
+				t.Errorf("ComputePingStats = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"test/models"
+	"time"
+)
+
+// maxTCPConversationBytes membatasi data yang dibaca selama satu percakapan
+const maxTCPConversationBytes = 64 << 10
+
+func init() {
+	Register(tcpProber{})
+}
+
+// tcpProber mengukur waktu TCP connect (seri PingCount dial per thread).
+// Jika opsi script diisi, setiap dial menjalankan percakapan send/expect;
+// opsi tls=1 membungkus koneksi dengan TLS.
+type tcpProber struct{}
+
+func (tcpProber) Name() string                        { return "tcp" }
+func (tcpProber) Label() string                       { return "TCP" }
+func (tcpProber) Samples(target models.TargetURL) int { return pingSamples(target) }
+
+func (tcpProber) Fields() []Field {
+	return withNetwork(withClientTLS([]Field{
+		pingCountField,
+		{Name: "opt_tls", Type: "select", Choices: []Choice{{"", "Plain TCP"}, {"1", "TLS"}}, Width: "160px"},
+		{Name: "opt_script", Type: "textarea", Rows: 3, Row: 1,
+			Placeholder: "Percakapan (opsional), satu langkah per baris\nsend: PING\\r\\n\nexpect: ^\\+PONG"},
+		proxyField,
+	}))
+}
+
+func (tcpProber) Configure(target *models.TargetURL, form url.Values) error {
+	if _, err := ParseTCPScript(target.Option("script", "")); err != nil {
+		return err
+	}
+	if err := configureProxy(target, form); err != nil {
+		return err
+	}
+	if err := configureClientTLS(target, form); err != nil {
+		return err
+	}
+	if err := configureNetwork(target, form); err != nil {
+		return err
+	}
+	return configurePingCount(target, form)
+}
+
+func (tcpProber) Probe(target models.TargetURL) ProbeResult {
+	useTLS := target.Option("tls", "") == "1"
+	script := target.Option("script", "")
+	if script == "" && !useTLS {
+		return DoTCPPing(target)
+	}
+
+	steps, err := ParseTCPScript(script)
+	if err != nil {
+		return ProbeResult{NetworkErr: true, Err: err, Description: "Invalid Script"}
+	}
+	defaultPort := "80"
+	if useTLS || strings.HasPrefix(target.URL, "https://") {
+		defaultPort = "443"
+	}
+	return doTCPConversation(target, hostPort(target.URL, defaultPort), steps, useTLS)
+}
+
+// tcpStep adalah satu langkah percakapan: kirim bytes (expect nil) atau tunggu pola
+type tcpStep struct {
+	send   []byte
+	expect *regexp.Regexp
+}
+
+// ParseTCPScript mengurai script percakapan TCP, satu langkah per baris:
+//
+//	send: PING\r\n
+//	expect: ^\+PONG
+//
+// Nilai send mendukung escape \r, \n, \t, \0, \\ dan \xNN. Nilai expect
+// adalah regex yang dicocokkan dengan data yang diterima sejak expect
+// sebelumnya. Baris kosong dan baris berawalan # diabaikan.
+func ParseTCPScript(text string) ([]tcpStep, error) {
+	var steps []tcpStep
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kind, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected 'send: ...' or 'expect: ...'", i+1)
+		}
+		value = strings.TrimPrefix(value, " ")
+		switch strings.TrimSpace(strings.ToLower(kind)) {
+		case "send":
+			data, err := unescapeBytes(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			steps = append(steps, tcpStep{send: data})
+		case "expect":
+			re, err := regexp.Compile(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			steps = append(steps, tcpStep{expect: re})
+		default:
+			return nil, fmt.Errorf("line %d: unknown step %q", i+1, kind)
+		}
+	}
+	return steps, nil
+}
+
+// unescapeBytes menerjemahkan escape sederhana (\r, \n, \t, \0, \\, \xNN)
+func unescapeBytes(s string) ([]byte, error) {
+	var out []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			out = append(out, s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'r':
+			out = append(out, '\r')
+		case 'n':
+			out = append(out, '\n')
+		case 't':
+			out = append(out, '\t')
+		case '0':
+			out = append(out, 0)
+		case '\\':
+			out = append(out, '\\')
+		case 'x':
+			if i+2 >= len(s) {
+				return nil, errors.New(`incomplete \x escape`)
+			}
+			b, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+			if err != nil {
+				return nil, fmt.Errorf(`invalid \x escape %q`, s[i-1:i+3])
+			}
+			out = append(out, byte(b))
+			i += 2
+		default:
+			out = append(out, '\\', s[i])
+		}
+	}
+	return out, nil
+}
+
+// doTCPConversation membuka koneksi (opsional TLS) ke addr lalu menjalankan
+// langkah-langkah script. Latency mencakup connect sampai langkah terakhir.
+func doTCPConversation(target models.TargetURL, addr string, steps []tcpStep, useTLS bool) ProbeResult {
+	startTime := time.Now()
+	deadline := startTime.Add(target.Timeout())
+
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	conn, err := dialTCP(ctx, target, addr)
+	if err != nil {
+		return tcpFailure(startTime, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(deadline)
+	if useTLS {
+		host, _, _ := net.SplitHostPort(addr)
+		tlsConfig, err := targetTLSConfig(target, host)
+		if err != nil {
+			return tlsConfigFailure(err)
+		}
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return tcpFailure(startTime, err)
+		}
+		conn = tlsConn
+	}
+
+	var received []byte
+	buf := make([]byte, 4096)
+	for _, step := range steps {
+		if step.expect == nil {
+			if _, err := conn.Write(step.send); err != nil {
+				return tcpFailure(startTime, err)
+			}
+			continue
+		}
+
+		// Baca sampai pola cocok, koneksi ditutup, atau deadline habis
+		for {
+			if loc := step.expect.FindIndex(received); loc != nil {
+				received = received[loc[1]:]
+				break
+			}
+			if len(received) >= maxTCPConversationBytes {
+				return tcpMismatch(startTime, step.expect, received)
+			}
+			n, err := conn.Read(buf)
+			received = append(received, buf[:n]...)
+			if err != nil {
+				if step.expect.Match(received) {
+					continue
+				}
+				if len(received) > 0 {
+					return tcpMismatch(startTime, step.expect, received)
+				}
+				return tcpFailure(startTime, err)
+			}
+		}
+	}
+
+	duration := time.Since(startTime)
+	return ProbeResult{
+		StatusCode: 200,
+		LatencyMs:  duration.Milliseconds(),
+		RTT:        duration,
+	}
+}
+
+func tcpFailure(startTime time.Time, err error) ProbeResult {
+	duration := time.Since(startTime)
+	return ProbeResult{
+		LatencyMs:  duration.Milliseconds(),
+		RTT:        duration,
+		NetworkErr: true,
+		Err:        err,
+	}
+}
+
+// tcpMismatch dipakai jika server menjawab tapi isinya tidak cocok dengan expect
+func tcpMismatch(startTime time.Time, expect *regexp.Regexp, received []byte) ProbeResult {
+	duration := time.Since(startTime)
+	return ProbeResult{
+		LatencyMs:   duration.Milliseconds(),
+		RTT:         duration,
+		Err:         fmt.Errorf("received %q does not match %q", truncateReply(string(bytes.TrimSpace(received))), expect.String()),
+		ErrorClass:  ErrorClassAssertion,
+		Description: "TCP Expect Mismatch: " + expect.String(),
+	}
+}
@@ -0,0 +1,99 @@
+package probe
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"test/models"
+	"time"
+)
+
+// httpTimer mencatat waktu tiap fase request HTTP lewat httptrace.
+// Callback trace bisa dipanggil dari goroutine lain (mis. resolver), jadi
+// aksesnya dijaga mutex.
+type httpTimer struct {
+	mu           sync.Mutex
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	gotConn      time.Time
+	firstByte    time.Time
+	bodyDone     time.Time
+}
+
+// withTrace memasang ClientTrace milik timer ke request
+func (t *httpTimer) withTrace(req *http.Request) *http.Request {
+	trace := &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { t.mark(&t.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { t.mark(&t.dnsDone) },
+		ConnectStart:         func(string, string) { t.markFirst(&t.connectStart) },
+		ConnectDone:          func(string, string, error) { t.mark(&t.connectDone) },
+		TLSHandshakeStart:    func() { t.mark(&t.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { t.mark(&t.tlsDone) },
+		GotConn:              func(httptrace.GotConnInfo) { t.mark(&t.gotConn) },
+		GotFirstResponseByte: func() { t.mark(&t.firstByte) },
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
+
+func (t *httpTimer) mark(field *time.Time) {
+	t.mu.Lock()
+	*field = time.Now()
+	t.mu.Unlock()
+}
+
+// markFirst hanya mencatat kejadian pertama (happy eyeballs bisa dial beberapa alamat)
+func (t *httpTimer) markFirst(field *time.Time) {
+	t.mu.Lock()
+	if field.IsZero() {
+		*field = time.Now()
+	}
+	t.mu.Unlock()
+}
+
+// timings menghitung durasi tiap fase dalam milidetik. Fase yang tidak terjadi
+// (mis. DNS/connect/TLS pada koneksi yang dipakai ulang) bernilai 0.
+func (t *httpTimer) timings() *models.HTTPTimings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return &models.HTTPTimings{
+		DNSMs:      phaseMs(t.dnsStart, t.dnsDone),
+		ConnectMs:  phaseMs(t.connectStart, t.connectDone),
+		TLSMs:      phaseMs(t.tlsStart, t.tlsDone),
+		TTFBMs:     phaseMs(t.gotConn, t.firstByte),
+		TransferMs: phaseMs(t.firstByte, t.bodyDone),
+	}
+}
+
+// classify mengklasifikasikan error request. Timeout dari http.Client tidak
+// menyebutkan fasenya, jadi fase ditentukan dari event trace yang sudah terjadi.
+func (t *httpTimer) classify(err error) ErrorClass {
+	class := ClassifyError(err)
+	if class != ErrorClassTimeout {
+		return class
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch {
+	case !t.dnsStart.IsZero() && t.dnsDone.IsZero():
+		return ErrorClassDNS
+	case t.gotConn.IsZero() && !t.tlsStart.IsZero() && t.tlsDone.IsZero():
+		return ErrorClassTLS
+	case t.gotConn.IsZero():
+		return ErrorClassConnectTimeout
+	}
+	return ErrorClassReadTimeout
+}
+
+func phaseMs(start, end time.Time) float64 {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return float64(end.Sub(start)) / float64(time.Millisecond)
+}
@@ -0,0 +1,180 @@
+package probe
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"test/models"
+	"time"
+)
+
+// defaultTLSWarnDays adalah batas hari sebelum expiry saat target mulai Warning
+const defaultTLSWarnDays = 14
+
+func init() {
+	Register(tlsProber{})
+}
+
+type tlsProber struct{}
+
+func (tlsProber) Name() string                              { return "tls" }
+func (tlsProber) Label() string                             { return "TLS" }
+func (tlsProber) Probe(target models.TargetURL) ProbeResult { return DoTLSProbe(target) }
+
+func (tlsProber) Fields() []Field {
+	return withNetwork(withClientTLS([]Field{
+		{Name: "opt_warn_days", Type: "number", Min: "0", Default: strconv.Itoa(defaultTLSWarnDays),
+			Placeholder: "Warning (hari sebelum expired)", Title: "Warning N hari sebelum sertifikat expired", Width: "260px"},
+	}))
+}
+
+func (tlsProber) Configure(target *models.TargetURL, form url.Values) error {
+	if v := target.Option("warn_days", ""); v != "" {
+		if days, err := strconv.Atoi(v); err != nil || days < 0 {
+			return fmt.Errorf("invalid warn_days %q", v)
+		}
+	}
+	if err := configureNetwork(target, form); err != nil {
+		return err
+	}
+	return configureClientTLS(target, form)
+}
+
+// DoTLSProbe melakukan TLS handshake ke host:port target (default 443) dan
+// memeriksa sertifikat leaf: masa berlaku, validitas chain dan hostname.
+// Opsi warn_days menentukan kapan target berubah menjadi Warning.
+func DoTLSProbe(target models.TargetURL) ProbeResult {
+	addr := hostPort(target.URL, "443")
+	host, _, _ := net.SplitHostPort(addr)
+
+	warnDays, err := strconv.Atoi(target.Option("warn_days", ""))
+	if err != nil || warnDays < 0 {
+		warnDays = defaultTLSWarnDays
+	}
+
+	tlsConfig, err := targetTLSConfig(target, host)
+	if err != nil {
+		return tlsConfigFailure(err)
+	}
+	roots := tlsConfig.RootCAs
+
+	ctx, cancel := context.WithTimeout(context.Background(), target.Timeout())
+	defer cancel()
+
+	startTime := time.Now()
+	// Verifikasi dilakukan manual setelah handshake supaya detail sertifikat
+	// tetap bisa dicatat walaupun chain tidak valid atau sudah expired.
+	tlsConfig.InsecureSkipVerify = true
+	conn, err := dialTLS(ctx, target, addr, tlsConfig)
+	duration := time.Since(startTime)
+
+	if err != nil {
+		return ProbeResult{
+			LatencyMs:   duration.Milliseconds(),
+			RTT:         duration,
+			NetworkErr:  true,
+			Err:         err,
+			Description: "TLS Handshake Failed",
+		}
+	}
+	state := conn.ConnectionState()
+	conn.Close()
+
+	if len(state.PeerCertificates) == 0 {
+		return ProbeResult{
+			LatencyMs:   duration.Milliseconds(),
+			RTT:         duration,
+			Err:         fmt.Errorf("%s presented no certificate", addr),
+			ErrorClass:  ErrorClassTLS,
+			Description: "No Certificate",
+		}
+	}
+
+	leaf := state.PeerCertificates[0]
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, verifyErr := leaf.Verify(x509.VerifyOptions{
+		DNSName:       host,
+		Intermediates: intermediates,
+		Roots:         roots,
+	})
+
+	info := &models.TLSInfo{
+		NotAfter:   leaf.NotAfter,
+		Issuer:     leaf.Issuer.String(),
+		SANs:       certificateSANs(leaf),
+		ChainValid: verifyErr == nil,
+		Version:    tls.VersionName(state.Version),
+		CheckedAt:  time.Now(),
+	}
+
+	result := ProbeResult{
+		StatusCode: 200,
+		LatencyMs:  duration.Milliseconds(),
+		RTT:        duration,
+		TLS:        info,
+	}
+
+	daysLeft := info.DaysLeft()
+	switch {
+	case time.Now().After(leaf.NotAfter):
+		result.StatusCode = 0
+		result.Err = fmt.Errorf("certificate expired at %s", leaf.NotAfter.Format(time.RFC3339))
+		result.ErrorClass = ErrorClassTLS
+		result.Description = "Certificate Expired"
+	case verifyErr != nil && !target.TLSSkipVerify:
+		result.StatusCode = 0
+		result.Err = verifyErr
+		result.ErrorClass = ErrorClassTLS
+		result.Description = "Certificate Invalid"
+	case daysLeft <= warnDays:
+		result.Status = "Warning"
+		result.Description = fmt.Sprintf("Certificate expires in %d days", daysLeft)
+	}
+	return result
+}
+
+// certificateSANs mengumpulkan DNS name dan IP dari Subject Alternative Name
+func certificateSANs(cert *x509.Certificate) []string {
+	sans := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	return sans
+}
+
+// hostPort mengambil host:port dari URL atau host mentah. defaultPort dipakai
+// jika target tidak menyebutkan port.
+func hostPort(rawURL string, defaultPort string) string {
+	if parsedURL, err := url.Parse(rawURL); err == nil && parsedURL.Host != "" {
+		if parsedURL.Port() != "" {
+			return parsedURL.Host
+		}
+		return net.JoinHostPort(parsedURL.Hostname(), defaultPort)
+	}
+	if _, _, err := net.SplitHostPort(rawURL); err == nil {
+		return rawURL
+	}
+	return net.JoinHostPort(rawURL, defaultPort)
+}
+
+// dialTLS membuka koneksi TCP ke addr dengan pengaturan jaringan target lalu
+// melakukan TLS handshake
+func dialTLS(ctx context.Context, target models.TargetURL, addr string, config *tls.Config) (*tls.Conn, error) {
+	rawConn, err := newTargetDialer(target).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	conn := tls.Client(rawConn, config)
+	if err := conn.HandshakeContext(ctx); err != nil {
+		rawConn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
@@ -0,0 +1,134 @@
+package probe
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"test/models"
+	"time"
+)
+
+// clientTLSFields dipakai mode yang membuka koneksi TLS (http, journey, ws,
+// tcp, grpc, tls). Pilihan sertifikat diisi handler dari tabel certificates.
+var clientTLSFields = []Field{
+	{Name: "client_cert_id", Type: "select", ChoicesFrom: models.CertKindClient, Width: "240px", Row: 8,
+		Title: "Client certificate (mTLS)"},
+	{Name: "ca_bundle_id", Type: "select", ChoicesFrom: models.CertKindCA, Width: "240px", Row: 8,
+		Title: "CA bundle untuk verifikasi sertifikat server"},
+	{Name: "tls_skip_verify", Type: "select", Choices: []Choice{{"", "Verifikasi TLS"}, {"1", "Skip verify (tidak aman)"}},
+		Width: "240px", Row: 8, Title: "Skip verify menerima sertifikat server apa pun"},
+}
+
+// withClientTLS menambahkan clientTLSFields ke field sebuah mode
+func withClientTLS(fields []Field) []Field {
+	return append(fields, clientTLSFields...)
+}
+
+// configureClientTLS membaca pengaturan sertifikat target dari form
+func configureClientTLS(target *models.TargetURL, form url.Values) error {
+	var err error
+	if target.ClientCertID, err = certificateID(form.Get("client_cert_id")); err != nil {
+		return err
+	}
+	if target.CABundleID, err = certificateID(form.Get("ca_bundle_id")); err != nil {
+		return err
+	}
+	target.TLSSkipVerify = form.Get("tls_skip_verify") == "1"
+	return nil
+}
+
+func certificateID(v string) (int, error) {
+	if v == "" {
+		return 0, nil
+	}
+	id, err := strconv.Atoi(v)
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("invalid certificate id %q", v)
+	}
+	return id, nil
+}
+
+// targetTLSConfig membangun tls.Config dari pengaturan sertifikat target.
+// serverName boleh kosong (http.Transport mengisinya sendiri per host).
+func targetTLSConfig(target models.TargetURL, serverName string) (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: target.TLSSkipVerify,
+	}
+	if target.ClientCertID != 0 {
+		if target.ClientCert == nil || target.ClientCert.Kind != models.CertKindClient {
+			return nil, fmt.Errorf("client certificate #%d not found", target.ClientCertID)
+		}
+		pair, err := tls.X509KeyPair([]byte(target.ClientCert.CertPEM), []byte(target.ClientCert.KeyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("client certificate %s: %w", target.ClientCert.Name, err)
+		}
+		cfg.Certificates = []tls.Certificate{pair}
+	}
+	if target.CABundleID != 0 {
+		pool, err := caBundlePool(target)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+// caBundlePool mengembalikan CA pool target (nil = CA sistem)
+func caBundlePool(target models.TargetURL) (*x509.CertPool, error) {
+	if target.CABundleID == 0 {
+		return nil, nil
+	}
+	if target.CABundle == nil || target.CABundle.Kind != models.CertKindCA {
+		return nil, fmt.Errorf("CA bundle #%d not found", target.CABundleID)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(target.CABundle.CertPEM)) {
+		return nil, fmt.Errorf("CA bundle %s has no valid certificates", target.CABundle.Name)
+	}
+	return pool, nil
+}
+
+// tlsConfigFailure dipakai saat sertifikat target tidak bisa dimuat
+func tlsConfigFailure(err error) ProbeResult {
+	return ProbeResult{NetworkErr: true, Err: err, ErrorClass: ErrorClassTLS, Description: "Invalid TLS Settings"}
+}
+
+// ParseCertificate memvalidasi sertifikat yang di-upload dan mengembalikan
+// subject serta masa berlaku sertifikat pertama. Kind client wajib punya
+// private key yang cocok; kind ca tidak boleh berisi private key.
+func ParseCertificate(kind, certPEM, keyPEM string) (subject string, notAfter time.Time, err error) {
+	block, _ := pem.Decode([]byte(certPEM))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return "", time.Time{}, errors.New("certificate is not a PEM CERTIFICATE")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	switch kind {
+	case models.CertKindClient:
+		if keyPEM == "" {
+			return "", time.Time{}, errors.New("client certificate needs a private key")
+		}
+		if _, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM)); err != nil {
+			return "", time.Time{}, err
+		}
+	case models.CertKindCA:
+		if keyPEM != "" {
+			return "", time.Time{}, errors.New("CA bundle must not contain a private key")
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM([]byte(certPEM)) {
+			return "", time.Time{}, errors.New("CA bundle has no valid certificates")
+		}
+	default:
+		return "", time.Time{}, fmt.Errorf("unknown certificate kind %q", kind)
+	}
+	return cert.Subject.String(), cert.NotAfter, nil
+}
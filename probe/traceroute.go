@@ -0,0 +1,236 @@
+package probe
+
+import (
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"test/models"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// Metode traceroute (models.Traceroute.Method)
+const (
+	TraceUDP  = "udp"
+	TraceTCP  = "tcp"
+	TraceICMP = "icmp"
+)
+
+const (
+	traceMaxHops    = 30
+	traceMaxSilent  = 5     // berhenti setelah sekian hop berturut-turut tanpa balasan
+	traceBasePort   = 33434 // port awal probe UDP, seperti traceroute klasik
+	traceHopTimeout = 2 * time.Second
+)
+
+// traceProbe mengirim satu probe dengan TTL ttl. Hasilnya IP yang membalas
+// (nil = tidak ada balasan) dan final jika balasan mengakhiri trace (tujuan
+// tercapai atau unreachable).
+type traceProbe func(ttl int) (hop net.IP, rtt time.Duration, final bool, err error)
+
+// Traceroutes menjalankan traceroute ke host target memakai resolver, keluarga
+// IP dan alamat sumber target (tanpa proxy): TCP SYN ke port layanan untuk mode
+// berbasis TCP atau UDP untuk mode lain, ditambah ICMP echo jika raw socket
+// diizinkan. UDP/TCP memakai IP_RECVERR sehingga tidak butuh hak istimewa.
+func Traceroutes(target models.TargetURL) []models.Traceroute {
+	method, port := tracePlan(target)
+	ip, err := resolveICMPTarget(target)
+	if err != nil {
+		return []models.Traceroute{{Method: method, Destination: targetHostname(target.URL), Error: err.Error()}}
+	}
+	source, err := icmpSource(target, ip)
+	if err != nil {
+		return []models.Traceroute{{Method: method, Destination: ip.String(), Error: err.Error()}}
+	}
+
+	destination := net.JoinHostPort(ip.String(), strconv.Itoa(port))
+	var probe traceProbe
+	if method == TraceTCP {
+		probe = tcpTraceProbe(ip, port, source)
+	} else {
+		probe = udpTraceProbe(ip, port, source)
+	}
+	traces := []models.Traceroute{runTrace(method, destination, ip, probe)}
+
+	conn, err := listenRawICMP(ip, source)
+	if err != nil {
+		// ICMP hanya pelengkap; tanpa raw socket cukup hasil UDP/TCP
+		return traces
+	}
+	defer conn.Close()
+	return append(traces, runTrace(TraceICMP, ip.String(), ip, icmpTraceProbe(conn, ip)))
+}
+
+// tracePlan memilih metode dan port traceroute untuk target: TCP ke port
+// layanan jika mode berbasis TCP dan port-nya diketahui, selain itu UDP
+func tracePlan(target models.TargetURL) (string, int) {
+	defaultPort := ""
+	switch target.ProbeMode {
+	case "http", "journey", "ws":
+		defaultPort = "80"
+		if strings.HasPrefix(target.URL, "https://") || strings.HasPrefix(target.URL, "wss://") {
+			defaultPort = "443"
+		}
+	case "tls", "grpc":
+		defaultPort = "443"
+	case "tcp": // port wajib ada di URL
+	default:
+		return TraceUDP, traceBasePort
+	}
+	_, portStr, err := net.SplitHostPort(hostPort(target.URL, defaultPort))
+	if port, perr := strconv.Atoi(portStr); err == nil && perr == nil && port > 0 {
+		return TraceTCP, port
+	}
+	return TraceUDP, traceBasePort
+}
+
+// runTrace mengirim probe dengan TTL 1, 2, ... sampai tujuan tercapai, probe
+// berakhir (unreachable), traceMaxHops, atau traceMaxSilent hop diam berturut-turut
+func runTrace(method, destination string, dst net.IP, probe traceProbe) models.Traceroute {
+	trace := models.Traceroute{Method: method, Destination: destination}
+	silent := 0
+	for ttl := 1; ttl <= traceMaxHops; ttl++ {
+		ip, rtt, final, err := probe(ttl)
+		if err != nil {
+			trace.Error = err.Error()
+			break
+		}
+		hop := models.TraceHop{TTL: ttl}
+		if ip != nil {
+			hop.IP = ip.String()
+			hop.RTTMs = float64(rtt.Microseconds()) / 1000
+			hop.Reached = ip.Equal(dst)
+			silent = 0
+		} else {
+			silent++
+		}
+		trace.Hops = append(trace.Hops, hop)
+		if hop.Reached {
+			trace.Reached = true
+			break
+		}
+		if final || silent >= traceMaxSilent {
+			break
+		}
+	}
+	return trace
+}
+
+// listenRawICMP membuka raw socket ICMP untuk traceroute. Socket datagram
+// (unprivileged) tidak menerima Time Exceeded, jadi hanya raw yang dipakai.
+func listenRawICMP(ip, source net.IP) (*icmp.PacketConn, error) {
+	rawNet, laddr := "ip4:icmp", "0.0.0.0"
+	if ip.To4() == nil {
+		rawNet, laddr = "ip6:ipv6-icmp", "::"
+	}
+	if source != nil {
+		laddr = source.String()
+	}
+	conn, err := icmp.ListenPacket(rawNet, laddr)
+	if errors.Is(err, os.ErrPermission) {
+		return nil, ErrICMPNotPermitted
+	}
+	return conn, err
+}
+
+// icmpTraceProbe mengirim ICMP echo dengan TTL terbatas lewat raw socket conn
+// dan mencocokkan Time Exceeded / Destination Unreachable dari router dengan
+// echo yang dikirim (ID dan sequence dari paket asli yang dikutip)
+func icmpTraceProbe(conn *icmp.PacketConn, dst net.IP) traceProbe {
+	var (
+		reqType   icmp.Type = ipv4.ICMPTypeEcho
+		replyType icmp.Type = ipv4.ICMPTypeEchoReply
+		unreach   icmp.Type = ipv4.ICMPTypeDestinationUnreachable
+		proto               = protocolICMP
+	)
+	v6 := dst.To4() == nil
+	if v6 {
+		reqType, replyType, proto = ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply, protocolIPv6ICMP
+		unreach = ipv6.ICMPTypeDestinationUnreachable
+	}
+	id := os.Getpid() & 0xffff
+
+	return func(ttl int) (net.IP, time.Duration, bool, error) {
+		var err error
+		if v6 {
+			err = conn.IPv6PacketConn().SetHopLimit(ttl)
+		} else {
+			err = conn.IPv4PacketConn().SetTTL(ttl)
+		}
+		if err != nil {
+			return nil, 0, false, err
+		}
+
+		seq := int(atomic.AddUint32(&icmpSeq, 1) & 0xffff)
+		msg := icmp.Message{Type: reqType, Body: &icmp.Echo{ID: id, Seq: seq, Data: []byte("probeMulti")}}
+		wb, err := msg.Marshal(nil)
+		if err != nil {
+			return nil, 0, false, err
+		}
+		if err := conn.SetDeadline(time.Now().Add(traceHopTimeout)); err != nil {
+			return nil, 0, false, err
+		}
+		startTime := time.Now()
+		if _, err := conn.WriteTo(wb, &net.IPAddr{IP: dst}); err != nil {
+			return nil, 0, false, err
+		}
+
+		rb := make([]byte, 1500)
+		for {
+			n, peer, err := conn.ReadFrom(rb)
+			if err != nil {
+				var netErr net.Error
+				if errors.As(err, &netErr) && netErr.Timeout() {
+					return nil, 0, false, nil
+				}
+				return nil, 0, false, err
+			}
+			reply, err := icmp.ParseMessage(proto, rb[:n])
+			if err != nil {
+				continue
+			}
+			peerIP := peer.(*net.IPAddr).IP
+			var quoted []byte
+			switch body := reply.Body.(type) {
+			case *icmp.Echo:
+				if reply.Type == replyType && body.ID == id && body.Seq == seq {
+					return peerIP, time.Since(startTime), true, nil
+				}
+				continue
+			case *icmp.TimeExceeded:
+				quoted = body.Data
+			case *icmp.DstUnreach:
+				quoted = body.Data
+			default:
+				continue
+			}
+			if !quotedEcho(quoted, v6, id, seq) {
+				continue
+			}
+			return peerIP, time.Since(startTime), reply.Type == unreach, nil
+		}
+	}
+}
+
+// quotedEcho memeriksa apakah paket asli yang dikutip pesan error ICMP adalah
+// echo request dengan id dan seq ini
+func quotedEcho(data []byte, v6 bool, id, seq int) bool {
+	headerLen := 40
+	if !v6 {
+		if len(data) < 1 {
+			return false
+		}
+		headerLen = int(data[0]&0x0f) * 4
+	}
+	if len(data) < headerLen+8 {
+		return false
+	}
+	echo := data[headerLen:]
+	return int(echo[4])<<8|int(echo[5]) == id && int(echo[6])<<8|int(echo[7]) == seq
+}
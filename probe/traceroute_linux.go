@@ -0,0 +1,206 @@
+//go:build linux
+
+package probe
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// sizeofSockExtendedErr adalah ukuran struct sock_extended_err:
+// errno(4) origin(1) type(1) code(1) pad(1) info(4) data(4)
+const sizeofSockExtendedErr = 16
+
+// udpTraceProbe mengirim datagram UDP dengan TTL terbatas ke port dst yang
+// naik setiap hop. Router membalas Time Exceeded, host tujuan Port Unreachable
+// (atau membalas datagram); keduanya dibaca dari error queue socket.
+func udpTraceProbe(dst net.IP, port int, source net.IP) traceProbe {
+	return func(ttl int) (net.IP, time.Duration, bool, error) {
+		fd, err := traceSocket(dst, unix.SOCK_DGRAM, ttl, source)
+		if err != nil {
+			return nil, 0, false, err
+		}
+		defer unix.Close(fd)
+
+		startTime := time.Now()
+		if err := unix.Sendto(fd, []byte("probeMulti"), 0, traceSockaddr(dst, port+ttl-1)); err != nil {
+			return nil, 0, false, err
+		}
+		for {
+			revents, err := tracePoll(fd, unix.POLLIN, startTime)
+			if err != nil || revents == 0 {
+				return nil, 0, false, err
+			}
+			if revents&unix.POLLERR != 0 {
+				hop, final, ok, err := readTraceError(fd, dst)
+				if err != nil || ok {
+					return hop, time.Since(startTime), final, err
+				}
+				continue
+			}
+			// Host tujuan membalas datagram: tujuan tercapai
+			unix.Recvfrom(fd, make([]byte, 512), unix.MSG_DONTWAIT)
+			return dst, time.Since(startTime), true, nil
+		}
+	}
+}
+
+// tcpTraceProbe membuka koneksi TCP (SYN) dengan TTL terbatas ke dst:port.
+// Koneksi berhasil atau ditolak (RST) berarti tujuan tercapai; Time Exceeded
+// dari router menggagalkan connect dan alamat router dibaca dari error queue.
+func tcpTraceProbe(dst net.IP, port int, source net.IP) traceProbe {
+	return func(ttl int) (net.IP, time.Duration, bool, error) {
+		fd, err := traceSocket(dst, unix.SOCK_STREAM|unix.SOCK_NONBLOCK, ttl, source)
+		if err != nil {
+			return nil, 0, false, err
+		}
+		defer unix.Close(fd)
+
+		startTime := time.Now()
+		if err := unix.Connect(fd, traceSockaddr(dst, port)); err != nil && !errors.Is(err, unix.EINPROGRESS) {
+			return nil, 0, false, err
+		}
+		revents, err := tracePoll(fd, unix.POLLOUT, startTime)
+		if err != nil || revents == 0 {
+			return nil, 0, false, err
+		}
+		rtt := time.Since(startTime)
+
+		soErr, err := unix.GetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_ERROR)
+		if err != nil {
+			return nil, 0, false, err
+		}
+		switch unix.Errno(soErr) {
+		case 0, unix.ECONNREFUSED:
+			return dst, rtt, true, nil
+		}
+		hop, final, ok, err := readTraceError(fd, dst)
+		if err != nil {
+			return nil, 0, false, err
+		}
+		if !ok {
+			// Gagal tanpa balasan ICMP, mis. tidak ada route dari host ini
+			return nil, 0, false, unix.Errno(soErr)
+		}
+		return hop, rtt, final, nil
+	}
+}
+
+// traceSocket membuat socket dengan TTL (hop limit) ttl dan IP_RECVERR
+// aktif, terikat ke source jika diisi
+func traceSocket(dst net.IP, sotype, ttl int, source net.IP) (int, error) {
+	family, level, ttlOpt, recvErrOpt := unix.AF_INET, unix.IPPROTO_IP, unix.IP_TTL, unix.IP_RECVERR
+	if dst.To4() == nil {
+		family, level, ttlOpt, recvErrOpt = unix.AF_INET6, unix.IPPROTO_IPV6, unix.IPV6_UNICAST_HOPS, unix.IPV6_RECVERR
+	}
+	fd, err := unix.Socket(family, sotype|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return -1, err
+	}
+	if err = unix.SetsockoptInt(fd, level, ttlOpt, ttl); err == nil {
+		err = unix.SetsockoptInt(fd, level, recvErrOpt, 1)
+	}
+	if err == nil && source != nil {
+		err = unix.Bind(fd, traceSockaddr(source, 0))
+	}
+	if err != nil {
+		unix.Close(fd)
+		return -1, err
+	}
+	return fd, nil
+}
+
+// traceSockaddr membuat sockaddr untuk ip:port
+func traceSockaddr(ip net.IP, port int) unix.Sockaddr {
+	if ip4 := ip.To4(); ip4 != nil {
+		sa := &unix.SockaddrInet4{Port: port}
+		copy(sa.Addr[:], ip4)
+		return sa
+	}
+	sa := &unix.SockaddrInet6{Port: port}
+	copy(sa.Addr[:], ip.To16())
+	return sa
+}
+
+// tracePoll menunggu event pada fd sampai traceHopTimeout sejak startTime.
+// revents 0 berarti timeout (hop tidak membalas).
+func tracePoll(fd int, events int16, startTime time.Time) (int16, error) {
+	for {
+		remaining := traceHopTimeout - time.Since(startTime)
+		if remaining <= 0 {
+			return 0, nil
+		}
+		fds := []unix.PollFd{{Fd: int32(fd), Events: events}}
+		n, err := unix.Poll(fds, int(remaining.Milliseconds())+1)
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if err != nil || n == 0 {
+			return 0, err
+		}
+		return fds[0].Revents, nil
+	}
+}
+
+// readTraceError membaca satu pesan ICMP dari error queue fd. ok false jika
+// pesan bukan balasan ICMP (mis. error lokal). final true untuk Destination
+// Unreachable, yang mengakhiri trace.
+func readTraceError(fd int, dst net.IP) (hop net.IP, final, ok bool, err error) {
+	oob := make([]byte, 512)
+	_, oobn, _, _, err := unix.Recvmsg(fd, make([]byte, 512), oob, unix.MSG_ERRQUEUE|unix.MSG_DONTWAIT)
+	if errors.Is(err, unix.EAGAIN) {
+		return nil, false, false, nil
+	}
+	if err != nil {
+		return nil, false, false, err
+	}
+	msgs, err := unix.ParseSocketControlMessage(oob[:oobn])
+	if err != nil {
+		return nil, false, false, err
+	}
+	for _, m := range msgs {
+		isV4 := m.Header.Level == unix.SOL_IP && m.Header.Type == unix.IP_RECVERR
+		isV6 := m.Header.Level == unix.SOL_IPV6 && m.Header.Type == unix.IPV6_RECVERR
+		if (!isV4 && !isV6) || len(m.Data) < sizeofSockExtendedErr {
+			continue
+		}
+		// sock_extended_err diikuti sockaddr pengirim ICMP (SO_EE_OFFENDER)
+		origin, icmpType := m.Data[4], m.Data[5]
+		if origin != unix.SO_EE_ORIGIN_ICMP && origin != unix.SO_EE_ORIGIN_ICMP6 {
+			continue
+		}
+		hop := offenderIP(m.Data[sizeofSockExtendedErr:])
+		if hop == nil {
+			continue
+		}
+		if origin == unix.SO_EE_ORIGIN_ICMP {
+			final = icmpType == 3 // Destination Unreachable
+		} else {
+			final = icmpType == 1
+		}
+		return hop, final || hop.Equal(dst), true, nil
+	}
+	return nil, false, false, nil
+}
+
+// offenderIP mengambil IP dari sockaddr_in / sockaddr_in6 mentah
+func offenderIP(sa []byte) net.IP {
+	if len(sa) < 2 {
+		return nil
+	}
+	switch binary.NativeEndian.Uint16(sa) {
+	case unix.AF_INET:
+		if len(sa) >= 8 {
+			return net.IP(append([]byte(nil), sa[4:8]...))
+		}
+	case unix.AF_INET6:
+		if len(sa) >= 24 {
+			return net.IP(append([]byte(nil), sa[8:24]...))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+
+package probe
+
+import (
+	"errors"
+	"net"
+	"time"
+)
+
+// Di luar Linux tidak ada IP_RECVERR, jadi hanya traceroute ICMP (raw socket)
+// yang tersedia
+var errTraceUnsupported = errors.New("traceroute: UDP/TCP tracing is only supported on Linux")
+
+func udpTraceProbe(dst net.IP, port int, source net.IP) traceProbe {
+	return func(int) (net.IP, time.Duration, bool, error) { return nil, 0, false, errTraceUnsupported }
+}
+
+func tcpTraceProbe(dst net.IP, port int, source net.IP) traceProbe {
+	return func(int) (net.IP, time.Duration, bool, error) { return nil, 0, false, errTraceUnsupported }
+}
@@ -0,0 +1,174 @@
+package probe
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"test/models"
+	"time"
+)
+
+// udpSendOnlyWait adalah jeda menunggu ICMP port unreachable saat target
+// tidak mengharapkan reply
+const udpSendOnlyWait = 300 * time.Millisecond
+
+// maxUDPReplyBytes cukup untuk satu datagram UDP
+const maxUDPReplyBytes = 64 << 10
+
+func init() {
+	Register(udpProber{})
+}
+
+// udpProber mengirim satu datagram ke host:port dan (opsional) menunggu reply.
+// Opsi target yang dipakai:
+//   - payload:        isi datagram
+//   - payload_format: text (default) atau hex
+//   - expect:         regex yang harus cocok dengan reply; kosong = tidak menunggu reply
+//
+// Untuk payload hex, expect dicocokkan dengan reply dalam bentuk hex.
+type udpProber struct{}
+
+func (udpProber) Name() string  { return "udp" }
+func (udpProber) Label() string { return "UDP" }
+
+func (udpProber) Fields() []Field {
+	return withNetwork([]Field{
+		{Name: "opt_payload_format", Type: "select", Choices: []Choice{{"text", "Text"}, {"hex", "Hex"}}, Width: "140px"},
+		{Name: "opt_payload", Type: "text", Placeholder: "Payload, contoh: ping atau 0x0a0b (hex)"},
+		{Name: "opt_expect", Type: "text", Placeholder: "Regex reply (kosong = tidak menunggu reply)"},
+	})
+}
+
+func (udpProber) Configure(target *models.TargetURL, form url.Values) error {
+	if _, port, err := net.SplitHostPort(hostPort(target.URL, "")); err != nil || port == "" {
+		return errors.New("udp target needs host:port")
+	}
+	if _, err := udpPayload(*target); err != nil {
+		return err
+	}
+	if expect := target.Option("expect", ""); expect != "" {
+		if _, err := regexp.Compile(expect); err != nil {
+			return fmt.Errorf("invalid expect regex: %w", err)
+		}
+	}
+	return configureNetwork(target, form)
+}
+
+func (udpProber) Probe(target models.TargetURL) ProbeResult {
+	return DoUDPProbe(target)
+}
+
+// DoUDPProbe mengirim payload ke host:port target. Jika expect diisi, probe
+// menunggu reply sampai timeout dan memeriksa isinya; jika tidak, probe hanya
+// memastikan datagram terkirim dan tidak dibalas ICMP port unreachable.
+func DoUDPProbe(target models.TargetURL) ProbeResult {
+	addr := hostPort(target.URL, "")
+	payload, err := udpPayload(target)
+	if err != nil {
+		return ProbeResult{NetworkErr: true, Err: err, Description: "Invalid Payload"}
+	}
+
+	var expect *regexp.Regexp
+	if pattern := target.Option("expect", ""); pattern != "" {
+		if expect, err = regexp.Compile(pattern); err != nil {
+			return ProbeResult{NetworkErr: true, Err: err, Description: "Invalid Expect Pattern"}
+		}
+	}
+
+	startTime := time.Now()
+	deadline := startTime.Add(target.Timeout())
+
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	conn, err := newTargetDialer(target).DialContext(ctx, "udp", addr)
+	if err != nil {
+		return udpFailure(startTime, err)
+	}
+	defer conn.Close()
+
+	// Socket UDP yang "connected" menerima error ICMP port unreachable
+	// sebagai ECONNREFUSED pada Read berikutnya
+	if err := conn.SetDeadline(deadline); err != nil {
+		return udpFailure(startTime, err)
+	}
+	sentAt := time.Now()
+	if _, err := conn.Write(payload); err != nil {
+		return udpFailure(startTime, err)
+	}
+
+	if expect == nil {
+		if wait := sentAt.Add(udpSendOnlyWait); wait.Before(deadline) {
+			conn.SetReadDeadline(wait)
+		}
+	}
+
+	buf := make([]byte, maxUDPReplyBytes)
+	n, err := conn.Read(buf)
+	rtt := time.Since(sentAt)
+
+	if err != nil {
+		var netErr net.Error
+		if expect == nil && errors.As(err, &netErr) && netErr.Timeout() {
+			// Tidak ada reply dan tidak ada port unreachable: dianggap terkirim
+			return ProbeResult{StatusCode: 200, LatencyMs: rtt.Milliseconds(), RTT: rtt}
+		}
+		return udpFailure(startTime, err)
+	}
+
+	result := ProbeResult{
+		StatusCode: 200,
+		LatencyMs:  rtt.Milliseconds(),
+		RTT:        rtt,
+	}
+	if expect != nil {
+		reply := string(buf[:n])
+		if target.Option("payload_format", "text") == "hex" {
+			reply = hex.EncodeToString(buf[:n])
+		}
+		if !expect.MatchString(reply) {
+			result.StatusCode = 0
+			result.ErrorClass = ErrorClassAssertion
+			result.Err = fmt.Errorf("reply %q does not match %q", truncateReply(reply), expect.String())
+			result.Description = "UDP Reply Mismatch"
+		}
+	}
+	return result
+}
+
+func udpFailure(startTime time.Time, err error) ProbeResult {
+	duration := time.Since(startTime)
+	return ProbeResult{
+		LatencyMs:  duration.Milliseconds(),
+		RTT:        duration,
+		NetworkErr: true,
+		Err:        err,
+	}
+}
+
+// udpPayload mengubah opsi payload menjadi byte sesuai payload_format
+func udpPayload(target models.TargetURL) ([]byte, error) {
+	payload := target.Option("payload", "")
+	if target.Option("payload_format", "text") != "hex" {
+		return []byte(payload), nil
+	}
+	cleaned := strings.NewReplacer(" ", "", ":", "", "\n", "").Replace(strings.TrimPrefix(payload, "0x"))
+	data, err := hex.DecodeString(cleaned)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex payload: %w", err)
+	}
+	return data, nil
+}
+
+// truncateReply memotong reply panjang supaya pesan error tetap ringkas
+func truncateReply(reply string) string {
+	const maxLen = 64
+	if len(reply) > maxLen {
+		return reply[:maxLen] + "..."
+	}
+	return reply
+}
@@ -0,0 +1,213 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"test/models"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func init() {
+	Register(wsProber{})
+}
+
+// wsProber melakukan upgrade WebSocket (ws:// atau wss://), lalu opsional
+// mengirim pesan dan menunggu reply.
+// Opsi target yang dipakai:
+//   - message: pesan teks yang dikirim setelah handshake
+//   - expect:  regex yang harus cocok dengan pesan yang diterima; kosong =
+//     pesan apa pun diterima (jika message diisi)
+type wsProber struct{}
+
+func (wsProber) Name() string  { return "ws" }
+func (wsProber) Label() string { return "WebSocket" }
+
+func (wsProber) Fields() []Field {
+	return withNetwork(withClientTLS([]Field{
+		{Name: "opt_message", Type: "text", Placeholder: "Pesan dikirim setelah handshake (opsional)"},
+		{Name: "opt_expect", Type: "text", Placeholder: "Regex reply (opsional)"},
+		{Name: "http_headers", Type: "textarea", Rows: 2, Row: 1, Placeholder: "Header handshake, satu per baris (opsional)\nAuthorization: Bearer ..."},
+		proxyField,
+		credentialField,
+	}))
+}
+
+func (wsProber) Configure(target *models.TargetURL, form url.Values) error {
+	target.URL = wsURL(target.URL)
+	if expect := target.Option("expect", ""); expect != "" {
+		if _, err := regexp.Compile(expect); err != nil {
+			return fmt.Errorf("invalid expect regex: %w", err)
+		}
+	}
+	target.HTTPHeaders = strings.TrimSpace(form.Get("http_headers"))
+	if err := configureClientTLS(target, form); err != nil {
+		return err
+	}
+	if err := configureCredential(target, form); err != nil {
+		return err
+	}
+	if err := configureNetwork(target, form); err != nil {
+		return err
+	}
+	return configureProxy(target, form)
+}
+
+func (wsProber) Probe(target models.TargetURL) ProbeResult {
+	return DoWSProbe(target)
+}
+
+// wsURL menormalkan URL ke skema ws/wss (http -> ws, https/tanpa skema -> wss)
+func wsURL(rawURL string) string {
+	switch {
+	case strings.HasPrefix(rawURL, "ws://"), strings.HasPrefix(rawURL, "wss://"):
+		return rawURL
+	case strings.HasPrefix(rawURL, "http://"):
+		return "ws://" + strings.TrimPrefix(rawURL, "http://")
+	case strings.HasPrefix(rawURL, "https://"):
+		return "wss://" + strings.TrimPrefix(rawURL, "https://")
+	}
+	return "wss://" + rawURL
+}
+
+// DoWSProbe melakukan handshake WebSocket dan (opsional) satu pertukaran
+// pesan. Latency handshake dan round-trip dicatat terpisah di WS; LatencyMs
+// adalah totalnya.
+func DoWSProbe(target models.TargetURL) ProbeResult {
+	var expect *regexp.Regexp
+	if pattern := target.Option("expect", ""); pattern != "" {
+		var err error
+		if expect, err = regexp.Compile(pattern); err != nil {
+			return ProbeResult{NetworkErr: true, Err: err, Description: "Invalid Expect Pattern"}
+		}
+	}
+	message := target.Option("message", "")
+
+	tlsConfig, err := targetTLSConfig(target, "")
+	if err != nil {
+		return tlsConfigFailure(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), target.Timeout())
+	defer cancel()
+
+	dialer := websocket.Dialer{
+		Proxy:            httpProxyFunc(target.ProxyURL),
+		HandshakeTimeout: target.Timeout(),
+		TLSClientConfig:  tlsConfig,
+		NetDialContext:   newTargetDialer(target).DialContext,
+	}
+	if proxyURL, _ := ParseProxyURL(target.ProxyURL); proxyURL != nil {
+		// Proxy eksplisit (termasuk socks5 dengan kredensial) lewat dialer sendiri
+		dialer.Proxy = nil
+		dialer.NetDialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+			return dialTCP(ctx, target, addr)
+		}
+	}
+
+	// Credential dipasang ke request handshake
+	handshakeReq := &http.Request{Header: ParseHeaderLines(target.HTTPHeaders)}
+	if err := applyCredential(handshakeReq, target); err != nil {
+		return credentialFailure(err)
+	}
+
+	startTime := time.Now()
+	conn, resp, err := dialer.DialContext(ctx, wsURL(target.URL), handshakeReq.Header)
+	handshake := time.Since(startTime)
+	timings := &models.WSTimings{HandshakeMs: durationMs(handshake)}
+
+	if err != nil {
+		result := ProbeResult{
+			LatencyMs:  handshake.Milliseconds(),
+			RTT:        handshake,
+			NetworkErr: true,
+			Err:        err,
+			WS:         timings,
+		}
+		if errors.Is(err, websocket.ErrBadHandshake) && resp != nil {
+			forgetRejectedToken(target, resp.StatusCode)
+			// Server menjawab HTTP tapi menolak upgrade
+			result.NetworkErr = false
+			result.StatusCode = 0
+			result.ErrorClass = ErrorClassHandshake
+			result.Err = fmt.Errorf("upgrade rejected with HTTP %d", resp.StatusCode)
+			result.Description = fmt.Sprintf("WebSocket Handshake Failed (HTTP %d)", resp.StatusCode)
+		}
+		return result
+	}
+	defer conn.Close()
+
+	result := ProbeResult{
+		StatusCode: 200,
+		LatencyMs:  handshake.Milliseconds(),
+		RTT:        handshake,
+		WS:         timings,
+	}
+	if message == "" && expect == nil {
+		conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+		return result
+	}
+
+	deadline, _ := ctx.Deadline()
+	conn.SetReadDeadline(deadline)
+	conn.SetWriteDeadline(deadline)
+
+	sentAt := time.Now()
+	if message != "" {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+			return wsFailure(result, err)
+		}
+	}
+
+	// Baca pesan sampai ada yang cocok dengan expect (atau pesan pertama jika
+	// expect kosong)
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return wsFailure(result, err)
+		}
+		if expect == nil || expect.Match(data) {
+			break
+		}
+	}
+	roundTrip := time.Since(sentAt)
+	timings.RoundTripMs = durationMs(roundTrip)
+
+	total := handshake + roundTrip
+	result.LatencyMs = total.Milliseconds()
+	result.RTT = total
+
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	return result
+}
+
+// wsFailure menandai hasil handshake yang sudah sukses sebagai gagal di tahap pesan
+func wsFailure(result ProbeResult, err error) ProbeResult {
+	result.StatusCode = 0
+	result.NetworkErr = true
+	result.Err = err
+	result.ErrorClass = ClassifyError(err)
+	if result.ErrorClass == ErrorClassTimeout {
+		// Handshake sudah selesai, jadi timeout di sini pasti saat menunggu pesan
+		result.ErrorClass = ErrorClassReadTimeout
+	}
+	// Pesan yang tidak cocok terus dibaca sampai deadline, jadi hanya read
+	// timeout yang berarti tidak ada balasan yang cocok
+	if result.ErrorClass == ErrorClassReadTimeout {
+		result.Description = "WebSocket No Matching Reply"
+	} else {
+		result.Description = result.ErrorClass.Label()
+	}
+	return result
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -2,7 +2,9 @@ package scheduler
 
 import (
 	"database/sql"
+	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"test/database"
 	"test/models"
@@ -12,6 +14,9 @@ import (
 	"github.com/robfig/cron/v3"
 )
 
+// pingInterval adalah jeda antar echo/dial dalam satu seri ping
+const pingInterval = 200 * time.Millisecond
+
 // CreateJob adalah fungsi yang mengembalikan fungsi job dengan FULL THREAD IMPLEMENTATION
 func CreateJob(store *database.Store) func() {
 	return func() {
@@ -34,6 +39,32 @@ func CreateJob(store *database.Store) func() {
 			schedulerThreadCount = 1
 		}
 
+		// Proxy global dipakai target yang tidak mengatur proxy sendiri
+		globalProxy, err := store.GetProxyURL()
+		if err != nil {
+			log.Printf("[CRON] Failed to get global proxy, probing without it: %v\n", err)
+		}
+
+		// Sertifikat (mTLS / CA bundle) dipasang ke target yang memakainya
+		certs, err := store.GetCertificates()
+		if err != nil {
+			log.Printf("[CRON] Failed to get certificates: %v\n", err)
+		}
+		certByID := make(map[int]*models.Certificate, len(certs))
+		for i := range certs {
+			certByID[certs[i].ID] = &certs[i]
+		}
+
+		// Credential (secret sudah didekripsi) untuk target yang memakai autentikasi
+		creds, err := store.GetCredentialsWithSecrets()
+		if err != nil {
+			log.Printf("[CRON] Failed to get credentials: %v\n", err)
+		}
+		credByID := make(map[int]*models.Credential, len(creds))
+		for i := range creds {
+			credByID[creds[i].ID] = &creds[i]
+		}
+
 		log.Printf("[CRON] Processing %d URLs with scheduler thread count: %d\n", len(urls), schedulerThreadCount)
 
 		// Semaphore untuk mengontrol berapa URL yang diproses bersamaan
@@ -42,6 +73,12 @@ func CreateJob(store *database.Store) func() {
 
 		// Process setiap URL
 		for _, u := range urls {
+			if u.ProxyURL == "" {
+				u.ProxyURL = globalProxy
+			}
+			u.ClientCert = certByID[u.ClientCertID]
+			u.CABundle = certByID[u.CABundleID]
+			u.Credential = credByID[u.CredentialID]
 			urlWaitGroup.Add(1)
 			go func(targetURL models.TargetURL) {
 				defer urlWaitGroup.Done()
@@ -52,72 +89,130 @@ func CreateJob(store *database.Store) func() {
 
 				log.Printf("[CRON] Processing URL: %s with %d threads\n", targetURL.URL, targetURL.ThreadCount)
 
-				// Jalankan probe sebanyak url.ThreadCount kali secara concurrent
-				probeSemaphore := make(chan struct{}, targetURL.ThreadCount)
-				var probeWaitGroup sync.WaitGroup
-
-				// Channel untuk mengumpulkan hasil probe
-				results := make(chan probe.ProbeResult, targetURL.ThreadCount)
-
-				// Lakukan probe sebanyak ThreadCount kali
-				for i := 0; i < targetURL.ThreadCount; i++ {
-					probeWaitGroup.Add(1)
-					go func(threadIndex int) {
-						defer probeWaitGroup.Done()
-
-						probeSemaphore <- struct{}{}
-						defer func() { <-probeSemaphore }()
-
-						// Pilih probe mode
-						var result probe.ProbeResult
-						switch targetURL.ProbeMode {
-						case "tcp":
-							result = probe.DoTCPPing(targetURL.URL)
-						case "icmp":
-							result = probe.DoICMPProbe(targetURL.URL)
-						default:
-							result = probe.DoHTTPProbe(targetURL.URL)
-						}
-
-						log.Printf("[CRON] Thread %d for %s -> Status: %d, Latency: %dms\n",
-							threadIndex+1, targetURL.URL, result.StatusCode, result.LatencyMs)
+				prober := probe.Lookup(targetURL.ProbeMode)
 
-						// Kirim result ke channel
-						results <- result
-					}(i)
+				// Mode seperti icmp/tcp mengirim satu seri ping (PingCount echo/dial) per thread
+				samples := 1
+				seriesProber, isPingMode := prober.(probe.SeriesProber)
+				if isPingMode {
+					samples = seriesProber.Samples(targetURL)
 				}
 
-				// Wait semua probe selesai
-				probeWaitGroup.Wait()
-				close(results)
+				// Target fan-out diprobe per alamat IP; jika resolve gagal, probe
+				// biasa dijalankan supaya kegagalan DNS tercatat seperti biasa
+				var results []probe.ProbeResult
+				var addresses []models.AddressResult
+				// Lewat proxy (mis. proxy global) semua alamat memakai jalur yang
+				// sama, jadi hasil per alamat tidak bermakna
+				fanOut := targetURL.FanOut
+				if fanOut && probe.UsesProxy(targetURL) {
+					log.Printf("[CRON] Fan-out for %s skipped: target is probed through a proxy\n", targetURL.URL)
+					fanOut = false
+				}
+				if fanOut {
+					if ips, err := probe.ResolveAddresses(targetURL); err == nil {
+						probe.PruneFanOutTransports(targetURL, ips)
+						results, addresses = runFanOut(prober, targetURL, samples, ips)
+					} else {
+						log.Printf("[CRON] Fan-out resolve for %s failed: %v\n", targetURL.URL, err)
+					}
+				}
+				if addresses == nil {
+					results = runProbes(prober, targetURL, samples)
+				}
 
 				// Kumpulkan semua hasil dan hitung average
+				var allResults []probe.ProbeResult
 				var totalLatency int64
 				var successCount int
 				var lastStatus int
 				var hasSuccess bool
+				var probeStatus, probeDescription string
+				var failDescription string
+				var tlsInfo *models.TLSInfo
+				var errorClass probe.ErrorClass
+				var steps []models.StepResult
+				var errorMessage string
+				var content *models.ContentSnapshot
 
-				for result := range results {
+				for _, result := range results {
+					allResults = append(allResults, result)
 					totalLatency += result.LatencyMs
+					// Probe yang secara eksplisit melaporkan Down (mis. assertion body
+					// gagal) tidak dihitung sebagai success walaupun status code-nya 200
+					passed := result.StatusCode > 0 && result.Status != "Down"
+					if passed {
+						if result.Status != "" {
+							probeStatus = result.Status
+						}
+						if result.Description != "" {
+							probeDescription = result.Description
+						}
+					} else if result.Description != "" {
+						failDescription = result.Description
+					}
+					if result.TLS != nil {
+						tlsInfo = result.TLS
+					}
+					if result.Content != nil {
+						content = result.Content
+					}
+					if result.ErrorClass != probe.ErrorClassNone {
+						errorClass = result.ErrorClass
+						errorMessage = probe.ErrorMessage(result.Err)
+						if errorMessage == "" {
+							// Assertion gagal tidak punya error Go; deskripsinya yang menjelaskan
+							errorMessage = result.Description
+						}
+					}
+					// Langkah journey: simpan satu run saja, utamakan run yang gagal
+					if len(result.Steps) > 0 && (steps == nil || result.StatusCode == 0) {
+						steps = result.Steps
+					}
 
 					// Track jika ada yang success
-					if result.StatusCode > 0 {
+					if passed {
 						lastStatus = result.StatusCode
 						hasSuccess = true
-						if result.StatusCode == 200 {
+						if targetURL.IsStatusAccepted(result.StatusCode) {
 							successCount++
 						}
 					}
 				}
 
+				// Minimal satu thread lolos berarti target Up dan status/deskripsi
+				// diambil dari thread yang lolos; jika tidak ada, dari thread yang gagal
+				if !hasSuccess {
+					probeDescription = failDescription
+				}
+
+				// Fan-out: selama sebagian alamat masih lolos, target tetap Up
+				// tetapi Degraded (status diambil dari alamat yang lolos)
+				failed := failedAddresses(addresses)
+				degraded := len(failed) > 0 && len(failed) < len(addresses)
+				if degraded {
+					hasSuccess = true
+					lastStatus = passedStatus(addresses)
+				}
+
 				// Hitung average latency dari semua thread
-				avgLatency := totalLatency / int64(targetURL.ThreadCount)
+				avgLatency := totalLatency / int64(len(allResults))
+
+				// Statistik RTT, jitter dan packet loss untuk mode ping
+				var pingStats models.PingStats
+				if isPingMode {
+					pingStats = probe.ComputePingStats(allResults)
+				}
+
+				// Rincian fase HTTP (DNS/connect/TLS/TTFB/transfer) rata-rata semua thread
+				timings := probe.AverageTimings(allResults)
+				wsTimings := probe.AverageWSTimings(allResults)
 
 				// Update database dengan hasil probe
 				// Logic uptime: jika ada minimal 1 success, dianggap UP
 				var newFirstUpTime sql.NullTime = targetURL.FirstUpTime
-				wasUp := (targetURL.LastStatus == 200)
-				isNowUp := hasSuccess && (lastStatus == 200)
+				wasUp := targetURL.IsUp
+				isNowUp := hasSuccess && targetURL.IsStatusAccepted(lastStatus)
 
 				if !wasUp && isNowUp {
 					newFirstUpTime = sql.NullTime{Time: time.Now(), Valid: true}
@@ -128,19 +223,70 @@ func CreateJob(store *database.Store) func() {
 				// Tentukan status dan deskripsi berdasarkan hasil
 				var status, description string
 				if hasSuccess {
-					if lastStatus == 200 {
+					if targetURL.IsStatusAccepted(lastStatus) {
 						status = "Up"
 						description = "Succeed"
 					} else if lastStatus == 429 {
-						status = "Up"
+						status = "Warning"
 						description = "Too Many Requests"
 					} else {
-						status = "Up"
-						description = "Warning"
+						status = "Warning"
+						description = fmt.Sprintf("Unexpected Status %d", lastStatus)
 					}
 				} else {
 					status = "Down"
 					description = "Network Error"
+					if errorClass != probe.ErrorClassNone {
+						description = errorClass.Label()
+					}
+				}
+				if hasSuccess && pingStats.PacketLossPct > 0 {
+					description = fmt.Sprintf("Packet Loss %.0f%%", pingStats.PacketLossPct)
+				}
+				// Status/deskripsi spesifik dari probe (mis. DNS NXDOMAIN, sertifikat hampir expired) lebih informatif
+				if hasSuccess && probeStatus != "" {
+					status = probeStatus
+				}
+				if probeDescription != "" {
+					description = probeDescription
+				}
+				if degraded {
+					status = "Degraded"
+					description = fmt.Sprintf("Degraded: %d/%d addresses down (%s)", len(failed), len(addresses), strings.Join(failed, ", "))
+				}
+
+				// Perubahan konten body (opsi content_watch) hanya dinilai saat
+				// target Up, supaya halaman error sementara tidak ikut tercatat
+				var contentChange *models.ContentChange
+				saveContent := false
+				if content != nil && isNowUp {
+					previous, contentErr := store.GetContentSnapshot(targetURL.ID)
+					if contentErr != nil {
+						log.Printf("[CRON] Failed to read content snapshot for %s: %v\n", targetURL.URL, contentErr)
+					} else if previous.Hash != content.Hash {
+						saveContent = true
+						if previous.Hash != "" {
+							diff, added, removed := probe.DiffSnapshots(previous, *content)
+							contentChange = &models.ContentChange{
+								URLID:   targetURL.ID,
+								OldHash: previous.Hash,
+								NewHash: content.Hash,
+								Added:   added,
+								Removed: removed,
+								Diff:    diff,
+							}
+							changed := fmt.Sprintf("Content Changed (+%d/-%d lines)", added, removed)
+							if added == 0 && removed == 0 {
+								changed = "Content Changed (beyond stored snapshot)"
+							}
+							if status == "Up" {
+								status = "Warning"
+								description = changed
+							} else {
+								description += "; " + changed
+							}
+						}
+					}
 				}
 
 				// Update stats di database
@@ -150,16 +296,55 @@ func CreateJob(store *database.Store) func() {
 					err = store.UpdateProbeNetworkError(targetURL.ID, avgLatency, newFirstUpTime)
 				}
 
+				// Simpan detail sertifikat terakhir (mode tls)
+				if err == nil && tlsInfo != nil {
+					err = store.SaveTLSInfo(targetURL.ID, *tlsInfo)
+				}
+				if err == nil && degraded != targetURL.Degraded {
+					err = store.SetDegraded(targetURL.ID, degraded)
+				}
+
 				// Selalu catat history
 				if err == nil {
-					err = store.AddProbeHistory(targetURL.ID, avgLatency, lastStatus, status, description)
+					var historyID int64
+					historyID, err = store.AddProbeHistory(models.ProbeHistory{
+						URLID:        targetURL.ID,
+						LatencyMs:    avgLatency,
+						StatusCode:   lastStatus,
+						Status:       status,
+						Description:  description,
+						ErrorClass:   string(errorClass),
+						ErrorMessage: errorMessage,
+						PingStats:    pingStats,
+						HTTPTimings:  timings,
+						WSTimings:    wsTimings,
+					})
+					if err == nil && len(steps) > 0 {
+						err = store.AddProbeSteps(historyID, steps)
+					}
+					if err == nil && len(addresses) > 0 {
+						err = store.AddProbeAddresses(historyID, addresses)
+					}
+					if err == nil && contentChange != nil {
+						contentChange.HistoryID = historyID
+						err = store.AddContentChange(*contentChange)
+					}
+					if err == nil && saveContent {
+						err = store.SaveContentSnapshot(targetURL.ID, *content)
+					}
+					// Insiden baru (Up -> Down karena tidak ada probe yang berhasil):
+					// ambil traceroute di background. Status yang tidak diterima
+					// berarti host tetap terjangkau, jadi tidak perlu traceroute.
+					if err == nil && wasUp && !hasSuccess {
+						go captureTraceroute(store, targetURL, historyID)
+					}
 				}
 
 				if err != nil {
 					log.Printf("[CRON] Failed to update DB for %s: %v\n", targetURL.URL, err)
 				} else {
-					log.Printf("[CRON] Completed %s -> Avg Status: %d, Avg Latency: %dms (from %d threads, %d success)\n",
-						targetURL.URL, lastStatus, avgLatency, targetURL.ThreadCount, successCount)
+					log.Printf("[CRON] Completed %s -> Avg Status: %d, Avg Latency: %dms (from %d probes, %d success)\n",
+						targetURL.URL, lastStatus, avgLatency, len(allResults), successCount)
 				}
 			}(u)
 		}
@@ -170,6 +355,172 @@ func CreateJob(store *database.Store) func() {
 	}
 }
 
+// tracing mencatat URL yang traceroute-nya sedang berjalan, supaya target
+// yang naik-turun cepat tidak menumpuk traceroute
+var tracing sync.Map
+
+// maxConcurrentTraceroutes membatasi traceroute yang berjalan bersamaan saat
+// banyak target turun sekaligus (mis. gangguan jaringan di sisi probe)
+const maxConcurrentTraceroutes = 4
+
+var traceSemaphore = make(chan struct{}, maxConcurrentTraceroutes)
+
+// captureTraceroute menjalankan traceroute ke host target dan menyimpannya
+// bersama baris history insiden
+func captureTraceroute(store *database.Store, targetURL models.TargetURL, historyID int64) {
+	if _, running := tracing.LoadOrStore(targetURL.ID, struct{}{}); running {
+		return
+	}
+	defer tracing.Delete(targetURL.ID)
+
+	traceSemaphore <- struct{}{}
+	defer func() { <-traceSemaphore }()
+
+	for _, trace := range probe.Traceroutes(targetURL) {
+		trace.URLID = targetURL.ID
+		trace.HistoryID = historyID
+		if err := store.AddTraceroute(trace); err != nil {
+			log.Printf("[CRON] Failed to save %s traceroute for %s: %v\n", trace.Method, targetURL.URL, err)
+			continue
+		}
+		log.Printf("[CRON] Traceroute %s to %s: %d hops, reached=%v\n", trace.Method, trace.Destination, len(trace.Hops), trace.Reached)
+	}
+}
+
+// runProbes menjalankan probe sebanyak target.ThreadCount kali secara
+// concurrent; setiap thread mengirim samples probe (seri ping)
+func runProbes(prober probe.Prober, targetURL models.TargetURL, samples int) []probe.ProbeResult {
+	probeSemaphore := make(chan struct{}, targetURL.ThreadCount)
+	var probeWaitGroup sync.WaitGroup
+
+	// Channel untuk mengumpulkan hasil probe
+	results := make(chan probe.ProbeResult, targetURL.ThreadCount*samples)
+
+	// Lakukan probe sebanyak ThreadCount kali
+	for i := 0; i < targetURL.ThreadCount; i++ {
+		probeWaitGroup.Add(1)
+		go func(threadIndex int) {
+			defer probeWaitGroup.Done()
+
+			probeSemaphore <- struct{}{}
+			defer func() { <-probeSemaphore }()
+
+			for seq := 0; seq < samples; seq++ {
+				if seq > 0 {
+					time.Sleep(pingInterval)
+				}
+
+				result := probe.Run(prober, targetURL)
+
+				log.Printf("[CRON] Thread %d for %s%s -> Status: %d, Latency: %dms\n",
+					threadIndex+1, targetURL.URL, addressSuffix(targetURL), result.StatusCode, result.LatencyMs)
+				if result.Err != nil {
+					log.Printf("[CRON] Thread %d for %s%s -> Error: %v\n", threadIndex+1, targetURL.URL, addressSuffix(targetURL), result.Err)
+				}
+
+				// Kirim result ke channel
+				results <- result
+			}
+		}(i)
+	}
+
+	// Wait semua probe selesai
+	probeWaitGroup.Wait()
+	close(results)
+
+	all := make([]probe.ProbeResult, 0, len(results))
+	for result := range results {
+		all = append(all, result)
+	}
+	return all
+}
+
+// runFanOut menjalankan runProbes untuk setiap alamat IP target secara
+// bersamaan (memakai IP override) dan meringkas hasil per alamat
+func runFanOut(prober probe.Prober, targetURL models.TargetURL, samples int, ips []string) ([]probe.ProbeResult, []models.AddressResult) {
+	perAddress := make([][]probe.ProbeResult, len(ips))
+	var wg sync.WaitGroup
+	for i, ip := range ips {
+		wg.Add(1)
+		go func(i int, ip string) {
+			defer wg.Done()
+			t := targetURL
+			t.ResolveIP = ip
+			perAddress[i] = runProbes(prober, t, samples)
+		}(i, ip)
+	}
+	wg.Wait()
+
+	var all []probe.ProbeResult
+	addresses := make([]models.AddressResult, 0, len(ips))
+	for i, ip := range ips {
+		all = append(all, perAddress[i]...)
+		addresses = append(addresses, summarizeAddress(targetURL, ip, perAddress[i]))
+	}
+	return all, addresses
+}
+
+// summarizeAddress meringkas hasil probe satu alamat: lolos jika ada probe
+// dengan status yang diterima dan tidak dilaporkan Down oleh prober
+func summarizeAddress(targetURL models.TargetURL, ip string, results []probe.ProbeResult) models.AddressResult {
+	a := models.AddressResult{IP: ip}
+	var totalLatency int64
+	for _, r := range results {
+		totalLatency += r.LatencyMs
+		if r.StatusCode > 0 {
+			a.StatusCode = r.StatusCode
+		}
+		if r.StatusCode > 0 && targetURL.IsStatusAccepted(r.StatusCode) && r.Status != "Down" {
+			a.Passed = true
+		} else if a.Error == "" {
+			switch {
+			case r.Description != "":
+				a.Error = r.Description
+			case r.Err != nil:
+				a.Error = probe.ErrorMessage(r.Err)
+			case r.StatusCode > 0:
+				a.Error = fmt.Sprintf("Unexpected Status %d", r.StatusCode)
+			}
+		}
+	}
+	if len(results) > 0 {
+		a.LatencyMs = totalLatency / int64(len(results))
+	}
+	if a.Passed {
+		a.Error = ""
+	}
+	return a
+}
+
+// failedAddresses mengembalikan IP yang gagal di run fan-out
+func failedAddresses(addresses []models.AddressResult) []string {
+	var failed []string
+	for _, a := range addresses {
+		if !a.Passed {
+			failed = append(failed, a.IP)
+		}
+	}
+	return failed
+}
+
+// passedStatus mengembalikan status code alamat pertama yang lolos
+func passedStatus(addresses []models.AddressResult) int {
+	for _, a := range addresses {
+		if a.Passed {
+			return a.StatusCode
+		}
+	}
+	return 0
+}
+
+// addressSuffix menandai log probe fan-out dengan alamat yang diprobe
+func addressSuffix(targetURL models.TargetURL) string {
+	if targetURL.FanOut && targetURL.ResolveIP != "" {
+		return " [" + targetURL.ResolveIP + "]"
+	}
+	return ""
+}
+
 // StartScheduler starts the cron job
 func StartScheduler(interval string, store *database.Store) (*cron.Cron, cron.EntryID) {
 	c := cron.New()
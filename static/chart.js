@@ -108,6 +108,39 @@ function buildChartData(historyData) {
     return { points, values };
 }
 
+// Fase request HTTP untuk stacked chart (hanya tampil jika history punya data fase)
+const HTTP_PHASES = [
+    { key: 'DNSMs', label: 'DNS', color: '#42a5f5' },
+    { key: 'ConnectMs', label: 'Connect', color: '#ab47bc' },
+    { key: 'TLSMs', label: 'TLS', color: '#ffa726' },
+    { key: 'TTFBMs', label: 'TTFB', color: '#ef5350' },
+    { key: 'TransferMs', label: 'Transfer', color: '#26a69a' }
+];
+
+function hasPhaseData(historyData) {
+    return (historyData || []).some(d => HTTP_PHASES.some(p => (d[p.key] || 0) > 0));
+}
+
+function buildPhaseDatasets(historyData, bucketMs) {
+    const sorted = [...(historyData || [])].sort((a, b) => new Date(a.Timestamp) - new Date(b.Timestamp));
+    return HTTP_PHASES.map(function (p, i) {
+        const points = sorted.map(d => ({ x: new Date(d.Timestamp).getTime(), y: d[p.key] || 0 }));
+        return {
+            label: p.label,
+            data: bucketAverage(points, bucketMs),
+            stack: 'phases',
+            // Area bertumpuk: fase pertama diisi dari sumbu, berikutnya dari fase sebelumnya
+            fill: i === 0 ? 'origin' : '-1',
+            borderColor: p.color,
+            backgroundColor: p.color + '55',
+            borderWidth: 1,
+            tension: 0.2,
+            pointRadius: 0,
+            pointHoverRadius: 4
+        };
+    });
+}
+
 function getDecimationSamples(range) {
     const r = range || '1d';
     const samples = {
@@ -331,6 +364,19 @@ function initChart(historyData, urlId, chartRange) {
         }
     };
 
+    // Data fase HTTP tersedia: tampilkan sebagai area bertumpuk di bawah garis total
+    if (hasPhaseData(historyData)) {
+        const total = config.data.datasets[0];
+        total.stack = 'total';
+        total.fill = false;
+        config.data.datasets.push(...buildPhaseDatasets(historyData, bucketMs));
+        config.options.scales.y.stacked = true;
+        config.options.plugins.legend = { display: true, labels: { color: 'rgba(255, 255, 255, 0.7)', boxWidth: 12 } };
+        config.options.plugins.tooltip.callbacks.label = function (ctx) {
+            return ctx.dataset.label + ': ' + (ctx.parsed.y || 0) + ' ms';
+        };
+    }
+
     latencyChartInstance = new Chart(canvas.getContext('2d'), config);
     // Default: zoom OFF, user double-click untuk ON/OFF zoom
     chartZoomActive = false;
@@ -348,6 +394,11 @@ function initChart(historyData, urlId, chartRange) {
                         const nextValues = nextPoints.map(p => p.y);
                         const nextPalette = pickUniformColor(nextValues);
                         latencyChartInstance.data.datasets[0].data = nextPoints;
+                        if (latencyChartInstance.data.datasets.length > 1) {
+                            buildPhaseDatasets(data, getBucketMs(range)).forEach(function (phase, i) {
+                                latencyChartInstance.data.datasets[i + 1].data = phase.data;
+                            });
+                        }
                         latencyChartInstance.data.datasets[0].borderColor = nextPalette.line;
                         latencyChartInstance.data.datasets[0].pointRadius = (nextPoints.length <= 2) ? 3 : 0;
                         latencyChartInstance.data.datasets[0].backgroundColor = function(ctx) {